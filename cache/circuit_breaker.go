@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开 命令未发送直接失败
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// DefaultBreakerResetTimeout 默认的熔断恢复时间
+const DefaultBreakerResetTimeout = 5 * time.Second
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker 熔断器 连续失败达到阈值后打开 经过resetTimeout进入半开状态放行一条探测命令
+// 探测成功则关闭 失败则重新打开 只统计连接、超时等非服务器回复的错误
+type circuitBreaker struct {
+	mu           sync.Mutex
+	threshold    int
+	resetTimeout time.Duration
+	state        int
+	failures     int
+	openedAt     time.Time
+	probing      bool
+}
+
+// allow 判断是否放行命令
+func (cb *circuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case breakerOpen:
+		if time.Since(cb.openedAt) < cb.resetTimeout {
+			return ErrCircuitOpen
+		}
+		cb.state = breakerHalfOpen
+		cb.probing = true
+		return nil
+	case breakerHalfOpen:
+		if cb.probing {
+			return ErrCircuitOpen
+		}
+		cb.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record 记录命令结果
+func (cb *circuitBreaker) record(err error) {
+	if err == ErrCircuitOpen {
+		return
+	}
+	failed := isBreakerFailure(err)
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case breakerHalfOpen:
+		cb.probing = false
+		if failed {
+			cb.state = breakerOpen
+			cb.openedAt = time.Now()
+		} else {
+			cb.state = breakerClosed
+			cb.failures = 0
+		}
+	case breakerClosed:
+		if !failed {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.threshold {
+			cb.state = breakerOpen
+			cb.openedAt = time.Now()
+		}
+	}
+}
+
+// release 调用方主动取消的命令不说明服务器是否可用 不计入结果 只释放探测名额
+func (cb *circuitBreaker) release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probing = false
+}
+
+// isBreakerFailure 未命中和服务器回复的错误(如WRONGTYPE)说明服务器可用 不算失败
+func isBreakerFailure(err error) bool {
+	if err == nil || err == Nil {
+		return false
+	}
+	var redisErr redis.Error
+	return !errors.As(err, &redisErr)
+}
+
+func (cb *circuitBreaker) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, cb.allow()
+}
+
+func (cb *circuitBreaker) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if cmd.Err() != ErrCircuitOpen && callerDone(ctx) {
+		cb.release()
+		return nil
+	}
+	cb.record(cmd.Err())
+	return nil
+}
+
+func (cb *circuitBreaker) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, cb.allow()
+}
+
+func (cb *circuitBreaker) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if isBreakerFailure(cmd.Err()) || cmd.Err() == ErrCircuitOpen {
+			err = cmd.Err()
+			break
+		}
+	}
+	if err != ErrCircuitOpen && callerDone(ctx) {
+		cb.release()
+		return nil
+	}
+	cb.record(err)
+	return nil
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.BreakerThreshold = 3
+		o.BreakerResetTimeout = 100 * time.Millisecond
+		o.MaxRetries = -1
+	})
+	rc.Set("k", "v", 0)
+	mr.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := rc.Get("k").Error; err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("failure %d = %v", i, err)
+		}
+	}
+	start := time.Now()
+	if err := rc.Get("k").Error; !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("breaker should be open, got %v", err)
+	}
+	if time.Since(start) > 20*time.Millisecond {
+		t.Fatal("an open breaker should fail fast")
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	mr.Set("app:test:k", "v")
+	time.Sleep(120 * time.Millisecond)
+	if got, err := rc.Get("k").GetString(); err != nil || got != "v" {
+		t.Fatalf("half-open probe = %q, %v", got, err)
+	}
+	if err := rc.Get("k").Error; err != nil {
+		t.Fatalf("breaker should be closed after a successful probe, got %v", err)
+	}
+}
+
+func TestCircuitBreakerReopensOnFailedProbe(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.BreakerThreshold = 1
+		o.BreakerResetTimeout = 50 * time.Millisecond
+		o.MaxRetries = -1
+	})
+	mr.Close()
+	_ = rc.Get("k")
+	time.Sleep(60 * time.Millisecond)
+	if err := rc.Get("k").Error; err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("probe should reach the server, got %v", err)
+	}
+	if err := rc.Get("k").Error; !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("failed probe should reopen the breaker, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresServerErrors(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.BreakerThreshold = 1 })
+	mr.HSet("app:test:h", "f", "v")
+	for i := 0; i < 3; i++ {
+		_ = rc.Get("missing")
+		_ = rc.Get("h")
+	}
+	if err := rc.Set("k", "v", 0).Error; err != nil {
+		t.Fatalf("misses and WRONGTYPE should not open the breaker, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresCallerContext(t *testing.T) {
+	rc, _ := newTestClient(t, func(o *Options) { o.BreakerThreshold = 1 })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, client := range []*RedisClient{rc, rc.WithTimeout(time.Second)} {
+		if err := client.GetCtx(ctx, "k").Error; !errors.Is(err, context.Canceled) {
+			t.Fatalf("cancelled context = %v", err)
+		}
+	}
+	if err := rc.Set("k", "v", 0).Error; err != nil {
+		t.Fatalf("the caller's own cancellation should not open the breaker, got %v", err)
+	}
+}
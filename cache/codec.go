@@ -0,0 +1,48 @@
+package cache
+
+import "encoding/json"
+
+// Codec 序列化方式 GetValue 写入和 Outcome 解析时使用 可通过 Options.Codec 替换
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec 默认的json序列化
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// codec 获取配置的序列化方式 未配置时使用json
+func (rc *RedisClient) codec() Codec {
+	if rc.opt.Codec == nil {
+		return JSONCodec{}
+	}
+	return rc.opt.Codec
+}
+
+// getCodec 获取生成该结果的客户端的序列化方式
+func (oc *Outcome) getCodec() Codec {
+	if oc.codec == nil {
+		return JSONCodec{}
+	}
+	return oc.codec
+}
+
+// unmarshal 开启压缩时先自动解压 再使用codec解析
+func (oc *Outcome) unmarshal(str string, v interface{}) error {
+	data := []byte(str)
+	if oc.decompress {
+		var err error
+		if data, err = decompress(data); err != nil {
+			return err
+		}
+	}
+	return oc.getCodec().Unmarshal(data, v)
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func TestCustomCodecRoundTrip(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.Codec = gobCodec{} })
+	want := testUser{Name: "bob", Age: 9}
+	if err := rc.Set("user", want, 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := mr.Get("app:test:user")
+	if bytes.HasPrefix([]byte(raw), []byte("{")) {
+		t.Fatalf("value stored as json: %q", raw)
+	}
+	var got testUser
+	if err := rc.Get("user").Unmarshal(&got); err != nil || got != want {
+		t.Fatalf("decoded %+v, %v", got, err)
+	}
+}
+
+func TestDefaultCodecIsJSON(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("user", testUser{Name: "bob"}, 0)
+	if raw, _ := mr.Get("app:test:user"); raw != `{"name":"bob","age":0}` {
+		t.Fatalf("stored %q", raw)
+	}
+}
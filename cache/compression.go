@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/golang/snappy"
+	"io"
+)
+
+// Compression 压缩方式
+type Compression int
+
+const (
+	CompressionNone Compression = iota
+	CompressionGzip
+	CompressionSnappy
+)
+
+// DefaultCompressThreshold 默认的压缩阈值 序列化后小于该字节数的值不压缩
+const DefaultCompressThreshold = 1024
+
+// 压缩后的内容以 0x00 加压缩方式开头 开启压缩的客户端读取时据此自动解压
+const compressMagic = 0x00
+
+// compress 序列化后的内容超过阈值时压缩 压缩失败时保持原样
+func (rc *RedisClient) compress(data []byte) []byte {
+	threshold := rc.opt.CompressThreshold
+	if threshold <= 0 {
+		threshold = DefaultCompressThreshold
+	}
+	if rc.opt.Compression == CompressionNone || len(data) < threshold {
+		return data
+	}
+	header := []byte{compressMagic, byte(rc.opt.Compression)}
+	switch rc.opt.Compression {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		buf.Write(header)
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return data
+		}
+		if err := w.Close(); err != nil {
+			return data
+		}
+		return buf.Bytes()
+	case CompressionSnappy:
+		return append(header, snappy.Encode(nil, data)...)
+	default:
+		return data
+	}
+}
+
+// decompress 根据开头的标识解压 未压缩的内容原样返回
+func decompress(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != compressMagic {
+		return data, nil
+	}
+	switch Compression(data[1]) {
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data[2:]))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case CompressionSnappy:
+		return snappy.Decode(nil, data[2:])
+	default:
+		return data, nil
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompressionRoundTrip(t *testing.T) {
+	for _, compression := range []Compression{CompressionGzip, CompressionSnappy} {
+		rc, mr := newTestClient(t, func(o *Options) { o.Compression = compression })
+		want := testUser{Name: strings.Repeat("bob", 1000), Age: 1}
+		if err := rc.Set("big", want, 0).Error; err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := mr.Get("app:test:big")
+		if raw[0] != compressMagic || Compression(raw[1]) != compression {
+			t.Fatalf("compression %d: value not compressed", compression)
+		}
+		if len(raw) >= len(want.Name) {
+			t.Fatalf("compression %d: %d bytes stored", compression, len(raw))
+		}
+		var got testUser
+		if err := rc.Get("big").Unmarshal(&got); err != nil || got != want {
+			t.Fatalf("compression %d: decoded %v", compression, err)
+		}
+	}
+}
+
+func TestCompressionSkipsSmallValues(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.Compression = CompressionGzip })
+	rc.Set("small", testUser{Name: "bob"}, 0)
+	if raw, _ := mr.Get("app:test:small"); raw != `{"name":"bob","age":0}` {
+		t.Fatalf("small value stored as %q", raw)
+	}
+}
+
+func TestCompressThreshold(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.Compression = CompressionSnappy
+		o.CompressThreshold = 10
+	})
+	rc.Set("user", testUser{Name: "bob"}, 0)
+	if raw, _ := mr.Get("app:test:user"); raw[0] != compressMagic {
+		t.Fatal("value above a custom threshold should be compressed")
+	}
+}
+
+// zeroPrefixCodec 输出以压缩标识开头的codec 用于验证未开启压缩时不会误解压
+type zeroPrefixCodec struct{}
+
+func (zeroPrefixCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := JSONCodec{}.Marshal(v)
+	return append([]byte{compressMagic, byte(CompressionGzip)}, data...), err
+}
+
+func (zeroPrefixCodec) Unmarshal(data []byte, v interface{}) error {
+	return JSONCodec{}.Unmarshal(data[2:], v)
+}
+
+func TestDecompressOnlyWhenCompressionEnabled(t *testing.T) {
+	rc, _ := newTestClient(t, func(o *Options) { o.Codec = zeroPrefixCodec{} })
+	want := testUser{Name: "bob", Age: 3}
+	if err := rc.Set("user", want, 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got testUser
+	if err := rc.Get("user").Unmarshal(&got); err != nil || got != want {
+		t.Fatalf("decoded %+v, %v", got, err)
+	}
+}
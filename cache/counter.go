@@ -0,0 +1,47 @@
+package cache
+
+// Counter 计数器 基于 Incr 系列命令
+type Counter struct {
+	rc  *RedisClient
+	key string
+}
+
+// Counter 获取一个计数器
+func (rc *RedisClient) Counter(key string) *Counter {
+	return &Counter{
+		rc:  rc,
+		key: key,
+	}
+}
+
+// Inc 自增1 返回自增后的值
+func (c *Counter) Inc() (int64, error) {
+	return counterValue(c.rc.Incr(c.key))
+}
+
+// Add 增加n 返回增加后的值
+func (c *Counter) Add(n int64) (int64, error) {
+	return counterValue(c.rc.IncrBy(c.key, n))
+}
+
+// Get 获取当前值 不存在时为0 计数变化频繁 直接读取redis 不经过本地缓存
+func (c *Counter) Get() (int64, error) {
+	cmd := c.rc.Runner().Get(c.rc.ctx, c.rc.GetKey(c.key))
+	return counterValue(c.rc.keyOutcome(c.key, cmd.Val(), cmd))
+}
+
+// Reset 归零 返回归零前的值
+func (c *Counter) Reset() (int64, error) {
+	return counterValue(c.rc.GetSet(c.key, 0))
+}
+
+// counterValue 解析计数 key不存在视为0
+func counterValue(oc *Outcome) (int64, error) {
+	if oc.IsNil() {
+		return 0, nil
+	}
+	if oc.Error != nil {
+		return 0, oc.Error
+	}
+	return oc.GetInt64()
+}
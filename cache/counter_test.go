@@ -0,0 +1,40 @@
+package cache
+
+import "testing"
+
+func TestCounter(t *testing.T) {
+	rc, mr := newTestClient(t)
+	c := rc.Counter("visits")
+
+	if n, err := c.Get(); err != nil || n != 0 {
+		t.Fatalf("Get on a missing counter = %d, %v", n, err)
+	}
+	if n, err := c.Inc(); err != nil || n != 1 {
+		t.Fatalf("Inc = %d, %v", n, err)
+	}
+	if n, err := c.Add(10); err != nil || n != 11 {
+		t.Fatalf("Add = %d, %v", n, err)
+	}
+	if n, err := c.Get(); err != nil || n != 11 {
+		t.Fatalf("Get = %d, %v", n, err)
+	}
+	if got, _ := mr.Get("app:test:visits"); got != "11" {
+		t.Fatalf("stored %q", got)
+	}
+	if n, err := c.Reset(); err != nil || n != 11 {
+		t.Fatalf("Reset should return the previous value, got %d, %v", n, err)
+	}
+	if n, err := c.Get(); err != nil || n != 0 {
+		t.Fatalf("Get after Reset = %d, %v", n, err)
+	}
+}
+
+func TestCounterReportsWrongType(t *testing.T) {
+	rc, _ := newTestClient(t)
+	if err := rc.Set("visits", "abc", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Counter("visits").Inc(); err == nil {
+		t.Fatal("incrementing a non-integer should fail")
+	}
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// HealthStatus 健康检查结果 可用于就绪探针
+// Masters 只在集群模式下统计 为能够PING通的主节点数量
+type HealthStatus struct {
+	Reachable bool
+	Latency   time.Duration
+	Version   string
+	Masters   int
+	Error     error
+}
+
+// Health 健康检查 测量一次PING的往返耗时 并通过INFO获取服务器版本
+func (rc *RedisClient) Health(ctx context.Context) HealthStatus {
+	ctx = rc.context(ctx)
+	var status HealthStatus
+	start := time.Now()
+	if err := rc.Runner().Ping(ctx).Err(); err != nil {
+		status.Error = err
+		return status
+	}
+	status.Latency = time.Since(start)
+	status.Reachable = true
+	info, err := rc.Runner().Info(ctx, "server").Result()
+	if err != nil {
+		status.Error = err
+		return status
+	}
+	status.Version = parseInfo(info)["Server"]["redis_version"]
+	if !rc.flag {
+		var masters int64
+		status.Error = rc.cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			if err := master.Ping(ctx).Err(); err != nil {
+				return err
+			}
+			atomic.AddInt64(&masters, 1)
+			return nil
+		})
+		status.Masters = int(masters)
+	}
+	return status
+}
+
+// Info 执行INFO并按段解析 返回 段名(如Server)->字段->值
+// 集群模式下返回的是随机一个节点的信息
+func (rc *RedisClient) Info(sections ...string) (map[string]map[string]string, error) {
+	info, err := rc.Runner().Info(rc.ctx, sections...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(info), nil
+}
+
+// parseInfo 解析INFO的输出 段以 # 开头 字段为 key:value 格式
+func parseInfo(info string) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	var section map[string]string
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == Null {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			section = make(map[string]string)
+			result[strings.TrimSpace(strings.TrimPrefix(line, "#"))] = section
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 || section == nil {
+			continue
+		}
+		section[line[:i]] = line[i+1:]
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+const fakeInfo = "# Server\r\nredis_version:7.2.4\r\nredis_mode:standalone\r\n\r\n# Clients\r\nconnected_clients:3\r\n"
+
+// answerInfo miniredis的INFO只支持clients段 这里返回固定的内容
+func answerInfo(mr *miniredis.Miniredis) {
+	mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if cmd != "INFO" {
+			return false
+		}
+		c.WriteBulk(fakeInfo)
+		return true
+	})
+}
+
+func TestHealthReportsVersionAndLatency(t *testing.T) {
+	rc, mr := newTestClient(t)
+	answerInfo(mr)
+	status := rc.Health(context.Background())
+	if !status.Reachable || status.Error != nil {
+		t.Fatalf("status = %+v", status)
+	}
+	if status.Version != "7.2.4" || status.Latency <= 0 {
+		t.Fatalf("status = %+v", status)
+	}
+	if status.Masters != 0 {
+		t.Fatal("Masters is only counted in cluster mode")
+	}
+}
+
+func TestHealthCountsClusterMasters(t *testing.T) {
+	mr := miniredis.RunT(t)
+	answerInfo(mr)
+	rc, err := NewRedisClient(&Options{AppName: "app", NameSpace: "test", Addr: []string{mr.Addr(), mr.Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	status := rc.Health(context.Background())
+	if !status.Reachable || status.Error != nil || status.Masters != 1 {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestHealthUnreachable(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.MaxRetries = -1 })
+	mr.Close()
+	status := rc.Health(context.Background())
+	if status.Reachable || status.Error == nil || status.Version != "" {
+		t.Fatalf("status = %+v", status)
+	}
+}
+
+func TestInfoParsesSections(t *testing.T) {
+	rc, mr := newTestClient(t)
+	answerInfo(mr)
+	info, err := rc.Info("server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info["Server"]["redis_version"] != "7.2.4" {
+		t.Fatalf("Server section = %v", info["Server"])
+	}
+	if info["Clients"]["connected_clients"] != "3" {
+		t.Fatalf("Clients section = %v", info["Clients"])
+	}
+}
+
+func TestParseInfoSkipsNoise(t *testing.T) {
+	info := parseInfo("stray:1\n# Keyspace\ndb0:keys=1,expires=0\nnot a field\n")
+	if len(info) != 1 || info["Keyspace"]["db0"] != "keys=1,expires=0" {
+		t.Fatalf("parsed %v", info)
+	}
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// MetricsHook 命令指标回调 每条命令执行完成后调用 可对接Prometheus等监控
+// name 为小写的redis命令名 未命中时err为 Nil
+type MetricsHook interface {
+	ObserveCommand(name string, duration time.Duration, err error)
+}
+
+// NoopMetricsHook 不做任何处理的默认实现
+type NoopMetricsHook struct{}
+
+func (NoopMetricsHook) ObserveCommand(string, time.Duration, error) {}
+
+type startKey struct{}
+
+// metricsHook 将go-redis的钩子转换为 MetricsHook 回调
+type metricsHook struct {
+	metrics MetricsHook
+}
+
+func (h metricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (h metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		h.metrics.ObserveCommand(cmd.Name(), time.Since(start), cmd.Err())
+	}
+	return nil
+}
+
+func (h metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+// AfterProcessPipeline 管道内的命令共用一次往返的耗时
+func (h metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		duration := time.Since(start)
+		for _, cmd := range cmds {
+			h.metrics.ObserveCommand(cmd.Name(), duration, cmd.Err())
+		}
+	}
+	return nil
+}
+
+// addHook 给当前使用的客户端添加钩子
+func (rc *RedisClient) addHook(hook redis.Hook) {
+	if rc.flag {
+		rc.single.AddHook(hook)
+	} else {
+		rc.cluster.AddHook(hook)
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type observed struct {
+	name string
+	err  error
+}
+
+type fakeMetrics struct {
+	mu       sync.Mutex
+	observed []observed
+}
+
+func (f *fakeMetrics) ObserveCommand(name string, duration time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.observed = append(f.observed, observed{name: name, err: err})
+}
+
+func (f *fakeMetrics) last() observed {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.observed) == 0 {
+		return observed{}
+	}
+	return f.observed[len(f.observed)-1]
+}
+
+func TestMetricsHookObservesCommands(t *testing.T) {
+	metrics := &fakeMetrics{}
+	rc, mr := newTestClient(t, func(o *Options) { o.MetricsHook = metrics })
+
+	if err := rc.HSet("user", "name", "bob").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := metrics.last(); got.name != "hset" || got.err != nil {
+		t.Fatalf("hset observed as %+v", got)
+	}
+
+	if err := rc.Get("missing").Error; !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Get missing: %v", err)
+	}
+	if got := metrics.last(); got.name != "get" || got.err != Nil {
+		t.Fatalf("get miss observed as %+v", got)
+	}
+
+	mr.SetError("boom")
+	_ = rc.Get("user").Error
+	mr.SetError("")
+	if got := metrics.last(); got.name != "get" || got.err == nil || got.err == Nil {
+		t.Fatalf("failed get observed as %+v", got)
+	}
+}
+
+func TestMetricsHookDefaultsToNoop(t *testing.T) {
+	rc, _ := newTestClient(t)
+	if err := rc.Set("k", "v", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+	"sync"
+	"time"
+)
+
+// DefaultLocalCacheTTL 默认的本地缓存有效期
+const DefaultLocalCacheTTL = time.Second
+
+// localInvalidateChannel 本地缓存失效通知的频道 与业务频道一样加统一前缀
+const localInvalidateChannel = "__local_cache_invalidate__"
+
+// localCache 进程内的LRU缓存 作为redis前面的一级缓存 key为完整key
+// 未开启时为nil 所有方法对nil安全
+type localCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	// channel 失效通知的完整频道名 WithNamespace 派生的客户端共用
+	channel string
+}
+
+type localEntry struct {
+	hook     string
+	value    string
+	expireAt time.Time
+}
+
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	if ttl <= 0 {
+		ttl = DefaultLocalCacheTTL
+	}
+	return &localCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// get 获取未过期的值 命中时移到队首
+func (lc *localCache) get(hook string) (string, bool) {
+	if lc == nil {
+		return Null, false
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	elem, ok := lc.entries[hook]
+	if !ok {
+		return Null, false
+	}
+	entry := elem.Value.(*localEntry)
+	if time.Now().After(entry.expireAt) {
+		lc.removeElement(elem)
+		return Null, false
+	}
+	lc.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set 写入值 超出容量时淘汰最久未使用的
+func (lc *localCache) set(hook string, value string) {
+	if lc == nil {
+		return
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	expireAt := time.Now().Add(lc.ttl)
+	if elem, ok := lc.entries[hook]; ok {
+		entry := elem.Value.(*localEntry)
+		entry.value, entry.expireAt = value, expireAt
+		lc.order.MoveToFront(elem)
+		return
+	}
+	lc.entries[hook] = lc.order.PushFront(&localEntry{hook: hook, value: value, expireAt: expireAt})
+	for lc.order.Len() > lc.size {
+		lc.removeElement(lc.order.Back())
+	}
+}
+
+// del 删除值
+func (lc *localCache) del(hooks ...string) {
+	if lc == nil {
+		return
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	for _, hook := range hooks {
+		if elem, ok := lc.entries[hook]; ok {
+			lc.removeElement(elem)
+		}
+	}
+}
+
+func (lc *localCache) removeElement(elem *list.Element) {
+	lc.order.Remove(elem)
+	delete(lc.entries, elem.Value.(*localEntry).hook)
+}
+
+// invalidate 写操作完成后使本地缓存失效 err为写操作的错误
+// 本地副本总是删除 写入成功时才通知其他实例删除各自的本地副本
+// 必须在写操作之后调用 否则并发的 Get 可能把旧值重新写入本地缓存
+func (rc *RedisClient) invalidate(err error, keys ...string) {
+	if rc.local == nil {
+		return
+	}
+	rc.invalidateHooks(err, rc.getHooks(keys)...)
+}
+
+// invalidateHooks 同 invalidate 参数为完整key
+func (rc *RedisClient) invalidateHooks(err error, hooks ...string) {
+	if rc.local == nil || len(hooks) == 0 {
+		return
+	}
+	rc.local.del(hooks...)
+	if err != nil {
+		return
+	}
+	payload, err := json.Marshal(hooks)
+	if err != nil {
+		return
+	}
+	_ = rc.Runner().Publish(rc.ctx, rc.local.channel, payload).Err()
+}
+
+// localWrite 管道中的一条写命令及其写入的key
+type localWrite struct {
+	keys []string
+	cmd  redis.Cmder
+}
+
+// invalidateWrites 管道执行后按每条命令的结果失效 成功的key合并为一次通知
+func (rc *RedisClient) invalidateWrites(writes []localWrite) {
+	var succeeded, failed []string
+	var err error
+	for _, write := range writes {
+		if write.cmd.Err() == nil {
+			succeeded = append(succeeded, write.keys...)
+		} else {
+			failed = append(failed, write.keys...)
+			err = write.cmd.Err()
+		}
+	}
+	rc.invalidate(nil, succeeded...)
+	rc.invalidate(err, failed...)
+}
+
+// listenInvalidation 订阅失效通知 断线后由 redis.PubSub 自动重连
+func (rc *RedisClient) listenInvalidation() {
+	rc.local.channel = rc.GetKey(localInvalidateChannel)
+	rc.invalidation = rc.subscribe(rc.local.channel)
+	go func(ch <-chan *redis.Message) {
+		for msg := range ch {
+			var hooks []string
+			if err := json.Unmarshal([]byte(msg.Payload), &hooks); err != nil {
+				continue
+			}
+			rc.local.del(hooks...)
+		}
+	}(rc.invalidation.Channel())
+}
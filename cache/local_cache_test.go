@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+func newLocalTestClient(t *testing.T) (*RedisClient, *countingHook) {
+	t.Helper()
+	rc, _ := newTestClient(t, func(o *Options) {
+		o.LocalCacheSize = 100
+		o.LocalCacheTTL = time.Minute
+	})
+	return rc, newCountingHook(rc)
+}
+
+func TestLocalCacheServesRepeatedGets(t *testing.T) {
+	rc, hook := newLocalTestClient(t)
+	rc.Set("k", "v", 0)
+
+	for i := 0; i < 3; i++ {
+		if got, err := rc.Get("k").GetString(); err != nil || got != "v" {
+			t.Fatalf("Get = %q, %v", got, err)
+		}
+	}
+	if n := hook.count("get"); n != 1 {
+		t.Fatalf("%d GETs reached redis, want 1", n)
+	}
+}
+
+func TestLocalCacheDelPurges(t *testing.T) {
+	rc, hook := newLocalTestClient(t)
+	rc.Set("k", "v", 0)
+	rc.Get("k")
+	rc.Del("k")
+	if !rc.Get("k").IsNil() {
+		t.Fatal("Get after Del should miss")
+	}
+	if n := hook.count("get"); n != 2 {
+		t.Fatalf("%d GETs reached redis, want 2", n)
+	}
+}
+
+func TestLocalCacheExpires(t *testing.T) {
+	rc, _ := newTestClient(t, func(o *Options) {
+		o.LocalCacheSize = 10
+		o.LocalCacheTTL = 20 * time.Millisecond
+	})
+	hook := newCountingHook(rc)
+	rc.Set("k", "v", 0)
+	rc.Get("k")
+	time.Sleep(30 * time.Millisecond)
+	rc.Get("k")
+	if n := hook.count("get"); n != 2 {
+		t.Fatalf("%d GETs reached redis, want the expired entry to be refetched", n)
+	}
+}
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	lc := newLocalCache(2, time.Minute)
+	lc.set("a", "1")
+	lc.set("b", "2")
+	lc.get("a")
+	lc.set("c", "3")
+	if _, ok := lc.get("b"); ok {
+		t.Fatal("b should have been evicted")
+	}
+	if v, ok := lc.get("a"); !ok || v != "1" {
+		t.Fatal("recently used a should stay")
+	}
+	var disabled *localCache
+	disabled.set("a", "1")
+	if _, ok := disabled.get("a"); ok {
+		t.Fatal("a nil local cache should never hit")
+	}
+}
+
+// 每个写操作之后 下一次 Get 都必须重新访问redis
+func TestLocalCacheInvalidatedByWrites(t *testing.T) {
+	rc, hook := newLocalTestClient(t)
+	writes := map[string]func(){
+		"Set":          func() { rc.Set("k", "w", 0) },
+		"SetXX":        func() { rc.SetXX("k", "w", 0) },
+		"SetKeepTTL":   func() { rc.SetKeepTTL("k", "w") },
+		"Append":       func() { rc.Append("k", "w") },
+		"SetRange":     func() { rc.SetRange("k", 0, "w") },
+		"SetBit":       func() { rc.SetBit("k", 1, 1) },
+		"GetSet":       func() { rc.GetSet("k", "w") },
+		"GetDel":       func() { rc.GetDel("k") },
+		"Unlink":       func() { rc.Unlink("k") },
+		"Rename":       func() { rc.Set("other", "w", 0); rc.Rename("other", "k") },
+		"RenameNX":     func() { rc.Del("k"); rc.Set("other", "w", 0); rc.RenameNX("other", "k") },
+		"MSet":         func() { rc.MSet("k", "w") },
+		"SetMany":      func() { rc.SetMany(map[string]interface{}{"k": "w"}, 0) },
+		"Pipeline":     func() { rc.Pipeline().Set("k", "w", 0).Exec() },
+		"PipeDel":      func() { rc.Pipeline().Del("k").Exec() },
+		"PipeIncr":     func() { rc.Pipeline().Del("k").Incr("k").Exec() },
+		"PipeSetNX":    func() { rc.Pipeline().Del("k").SetNX("k", "w", 0).Exec() },
+		"Tx":           func() { rc.Transact([]string{"k"}, func(tx *Tx) error { tx.Set("k", "w", 0); return nil }) },
+		"TxDel":        func() { rc.Transact([]string{"k"}, func(tx *Tx) error { tx.Del("k"); return nil }) },
+		"DelByPattern": func() { rc.DelByPattern("k*") },
+		"Flush":        func() { rc.FlushNamespace() },
+		"Eval":         func() { rc.Eval(`return redis.call("SET", KEYS[1], "w")`, []string{"k"}) },
+	}
+	for name, write := range writes {
+		rc.Set("k", "v", 0)
+		rc.Get("k")
+		before := hook.count("get")
+		write()
+		rc.Get("k")
+		if hook.count("get") == before {
+			t.Errorf("%s did not invalidate the local cache", name)
+		}
+	}
+}
+
+func TestLocalCacheInvalidatedByRestore(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.LocalCacheSize = 10 })
+	registerDump(t, mr)
+	rc.Set("src", "new", 0)
+	payload, _ := rc.Dump("src").GetString()
+	rc.Set("k", "old", 0)
+	rc.Get("k")
+	mr.Del(rc.GetKey("k"))
+	if err := rc.Restore("k", 0, payload).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := rc.Get("k").GetString(); got != "new" {
+		t.Fatalf("Get after Restore = %q", got)
+	}
+}
+
+func TestLocalCacheInvalidatedByRateLimit(t *testing.T) {
+	rc, _ := newLocalTestClient(t)
+	if _, _, err := rc.Allow("k", 10, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := rc.Get("k").GetString(); got != "1" {
+		t.Fatalf("Get = %q", got)
+	}
+	if _, _, err := rc.Allow("k", 10, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := rc.Get("k").GetString(); got != "2" {
+		t.Fatalf("Get after Allow = %q, want the new count", got)
+	}
+}
+
+func TestCounterGetBypassesLocalCache(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.LocalCacheSize = 10
+		o.LocalCacheTTL = time.Minute
+	})
+	counter := rc.Counter("hits")
+	if _, err := counter.Inc(); err != nil {
+		t.Fatal(err)
+	}
+	rc.Get("hits")
+	if _, err := mr.Incr(rc.GetKey("hits"), 4); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := counter.Get(); err != nil || n != 5 {
+		t.Fatalf("Counter.Get = %d, %v; want the value in redis", n, err)
+	}
+}
+
+func TestLocalCacheInvalidatedAcrossClients(t *testing.T) {
+	a, mr := newTestClient(t, func(o *Options) { o.LocalCacheSize = 10; o.LocalCacheTTL = time.Minute })
+	b, err := NewRedisClient(&Options{
+		AppName:        "app",
+		NameSpace:      "test",
+		Addr:           []string{mr.Addr()},
+		LocalCacheSize: 10,
+		LocalCacheTTL:  time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	a.Set("k", "v1", 0)
+	if got, _ := b.Get("k").GetString(); got != "v1" {
+		t.Fatalf("b Get = %q", got)
+	}
+	a.Set("k", "v2", 0)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := b.local.get(b.GetKey("k")); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("b still holds the stale local entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got, _ := b.Get("k").GetString(); got != "v2" {
+		t.Fatalf("b Get after invalidation = %q", got)
+	}
+}
+
+func TestLocalCacheFailedWriteDoesNotPublish(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.LocalCacheSize = 10 })
+	hook := newCountingHook(rc)
+	mr.HSet(rc.GetKey("h"), "f", "v")
+	if err := rc.Append("h", "x").Error; err == nil {
+		t.Fatal("APPEND on a hash should fail")
+	}
+	if n := hook.count("publish"); n != 0 {
+		t.Fatalf("failed write published %d invalidations", n)
+	}
+	rc.Set("k", "v", 0)
+	if n := hook.count("publish"); n != 1 {
+		t.Fatalf("successful write published %d invalidations, want 1", n)
+	}
+	rc.Pipeline().Set("a", "1", 0).Set("b", "2", 0).Exec()
+	if n := hook.count("publish"); n != 2 {
+		t.Fatalf("a pipeline should publish once, got %d total", n)
+	}
+}
+
+func TestLocalCacheInvalidatedByJSONWrites(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.LocalCacheSize = 10
+		o.LocalCacheTTL = time.Minute
+	})
+	hook := newCountingHook(rc)
+	// 只关心写命令成功后的失效 不模拟文档内容
+	for name, reply := range map[string]func(c *server.Peer){
+		"JSON.SET": func(c *server.Peer) { c.WriteOK() },
+		"JSON.DEL": func(c *server.Peer) { c.WriteInt(1) },
+	} {
+		reply := reply
+		if err := mr.Server().Register(name, func(c *server.Peer, cmd string, args []string) { reply(c) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writes := map[string]func(){
+		"JSONSet": func() { rc.JSONSet("k", "$", map[string]int{"a": 1}) },
+		"JSONDel": func() { rc.JSONDel("k", "$") },
+	}
+	for name, write := range writes {
+		rc.Set("k", "v", 0)
+		rc.Get("k")
+		before := hook.count("get")
+		write()
+		rc.Get("k")
+		if hook.count("get") == before {
+			t.Errorf("%s did not invalidate the local cache", name)
+		}
+	}
+}
+
+func TestLocalCacheMSetInvalidatesBinaryKeys(t *testing.T) {
+	rc, _ := newLocalTestClient(t)
+	rc.Set("k", "old", 0)
+	if got, _ := rc.Get("k").GetString(); got != "old" {
+		t.Fatalf("Get = %q", got)
+	}
+	// []byte 与 string 得到相同的完整key 本地缓存也要按完整key失效
+	if err := rc.MSet([]byte("k"), "new").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := rc.Get("k").GetString(); got != "new" {
+		t.Fatalf("Get after MSet = %q, want new", got)
+	}
+}
+
+func TestLocalCacheInvalidatedByTokens(t *testing.T) {
+	rc, _ := newLocalTestClient(t)
+	token, ok, err := rc.SetNXToken("lock", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("SetNXToken = %v, %v", ok, err)
+	}
+	if got, _ := rc.Get("lock").GetString(); got != token {
+		t.Fatalf("Get after SetNXToken = %q, want the token", got)
+	}
+	if released, err := rc.ReleaseToken("lock", token); err != nil || !released {
+		t.Fatalf("ReleaseToken = %v, %v", released, err)
+	}
+	if !rc.Get("lock").IsNil() {
+		t.Fatal("Get after ReleaseToken should miss")
+	}
+}
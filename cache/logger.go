@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// Logger 日志接口 通过 Options.Logger 设置 命令出错时调用Errorf 慢命令调用Debugf
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NoopLogger 不输出任何日志的默认实现
+type NoopLogger struct{}
+
+func (NoopLogger) Debugf(string, ...interface{}) {}
+
+func (NoopLogger) Errorf(string, ...interface{}) {}
+
+type logStartKey struct{}
+
+// logHook 记录命令错误和慢命令 未命中不算错误 slow为0时不检测慢命令
+// 慢命令同时调用 Options.OnSlow 管道整体计时 cmd为pipeline key为空
+type logHook struct {
+	logger Logger
+	slow   time.Duration
+	onSlow func(cmd, key string, d time.Duration)
+}
+
+func (h logHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, logStartKey{}, time.Now()), nil
+}
+
+func (h logHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if err := cmd.Err(); err != nil && err != Nil {
+		h.logger.Errorf("redis %s on key %q: %v", cmd.Name(), commandKey(cmd), err)
+	}
+	if start, ok := ctx.Value(logStartKey{}).(time.Time); ok {
+		if duration := time.Since(start); h.slow > 0 && duration >= h.slow {
+			h.logger.Debugf("redis slow %s on key %q took %s", cmd.Name(), commandKey(cmd), duration)
+			if h.onSlow != nil {
+				h.onSlow(cmd.Name(), commandKey(cmd), duration)
+			}
+		}
+	}
+	return nil
+}
+
+func (h logHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, logStartKey{}, time.Now()), nil
+}
+
+func (h logHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && err != Nil {
+			h.logger.Errorf("redis pipeline %s on key %q: %v", cmd.Name(), commandKey(cmd), err)
+		}
+	}
+	if start, ok := ctx.Value(logStartKey{}).(time.Time); ok {
+		if duration := time.Since(start); h.slow > 0 && duration >= h.slow {
+			h.logger.Debugf("redis slow pipeline of %d commands took %s", len(cmds), duration)
+			if h.onSlow != nil {
+				h.onSlow("pipeline", Null, duration)
+			}
+		}
+	}
+	return nil
+}
+
+// commandKey 命令的第一个参数即为key 没有参数时返回空字符串
+func commandKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return Null
+	}
+	return fmt.Sprint(args[1])
+}
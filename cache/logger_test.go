@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+type captureLogger struct {
+	mu     sync.Mutex
+	debugs []string
+	errors []string
+}
+
+func (l *captureLogger) Debugf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debugs = append(l.debugs, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Errorf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+// delayCommand 让指定命令在服务器端延迟执行
+func delayCommand(mr *miniredis.Miniredis, name string, delay time.Duration) {
+	mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if cmd == name {
+			time.Sleep(delay)
+		}
+		return false
+	})
+}
+
+func TestLoggerRecordsSlowCommands(t *testing.T) {
+	logger := &captureLogger{}
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.Logger = logger
+		o.SlowThreshold = 30 * time.Millisecond
+	})
+	rc.Set("fast", "v", 0)
+	delayCommand(mr, "GET", 50*time.Millisecond)
+	rc.Get("slow")
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.debugs) != 1 {
+		t.Fatalf("logged %v, want only the slow GET", logger.debugs)
+	}
+	if msg := logger.debugs[0]; !strings.Contains(msg, "slow get") || !strings.Contains(msg, "app:test:slow") {
+		t.Fatalf("slow log = %q", msg)
+	}
+}
+
+func TestLoggerRecordsErrorsButNotMisses(t *testing.T) {
+	logger := &captureLogger{}
+	rc, mr := newTestClient(t, func(o *Options) { o.Logger = logger })
+	rc.Get("missing")
+	mr.HSet("app:test:h", "f", "v")
+	rc.Get("h")
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.errors) != 1 || !strings.Contains(logger.errors[0], "WRONGTYPE") {
+		t.Fatalf("errors = %v", logger.errors)
+	}
+	if len(logger.debugs) != 0 {
+		t.Fatalf("slow detection should be off without a threshold, got %v", logger.debugs)
+	}
+}
+
+func TestOnSlowReportsDuration(t *testing.T) {
+	type slowCall struct {
+		cmd, key string
+		d        time.Duration
+	}
+	calls := make(chan slowCall, 10)
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.SlowThreshold = 30 * time.Millisecond
+		o.OnSlow = func(cmd, key string, d time.Duration) { calls <- slowCall{cmd, key, d} }
+	})
+	rc.Set("fast", "v", 0)
+	delayCommand(mr, "HGET", 60*time.Millisecond)
+	start := time.Now()
+	rc.HGet("user", "name")
+	elapsed := time.Since(start)
+
+	select {
+	case call := <-calls:
+		if call.cmd != "hget" || call.key != "app:test:user" {
+			t.Fatalf("OnSlow(%q, %q)", call.cmd, call.key)
+		}
+		if call.d < 60*time.Millisecond || call.d > elapsed {
+			t.Fatalf("reported %s, delay 60ms, call took %s", call.d, elapsed)
+		}
+	default:
+		t.Fatal("OnSlow was not called")
+	}
+	if len(calls) != 0 {
+		t.Fatal("fast commands should not be reported")
+	}
+}
+
+func TestOnSlowForPipelines(t *testing.T) {
+	var got string
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.SlowThreshold = 30 * time.Millisecond
+		o.OnSlow = func(cmd, key string, d time.Duration) { got = cmd }
+	})
+	delayCommand(mr, "SET", 40*time.Millisecond)
+	rc.Pipeline().Set("a", "1", 0).Exec()
+	if got != "pipeline" {
+		t.Fatalf("OnSlow cmd = %q", got)
+	}
+}
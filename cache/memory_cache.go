@@ -0,0 +1,852 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrWrongType key中保存的数据类型与命令不匹配 与redis的WRONGTYPE错误对应
+var ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// errNotInteger 值不是整数
+var errNotInteger = errors.New("ERR value is not an integer or out of range")
+
+// errNotFloat 值不是浮点数
+var errNotFloat = errors.New("ERR value is not a valid float")
+
+// MemoryCache 进程内的 Cache 实现 用于下游服务的单元测试 不需要真实的redis
+// 值的序列化和 Outcome 的类型与 RedisClient 保持一致 过期的key在访问时删除
+type MemoryCache struct {
+	mu    sync.Mutex
+	items map[string]*memoryItem
+}
+
+// memoryItem value的类型为 string、map[string]string(hash)、[]string(list)
+// map[string]struct{}(set) 或 map[string]float64(zset)
+type memoryItem struct {
+	value    interface{}
+	expireAt time.Time
+}
+
+var _ Cache = (*MemoryCache)(nil)
+
+// NewMemoryCache 实例化一个进程内缓存
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{items: make(map[string]*memoryItem)}
+}
+
+// outcome 生成统一返回值 未命中使用 ErrCacheMiss
+func (mc *MemoryCache) outcome(value interface{}, err error) *Outcome {
+	if err != nil {
+		return &Outcome{Error: err}
+	}
+	return &Outcome{Primordial: value}
+}
+
+// item 获取未过期的key 已过期的直接删除
+func (mc *MemoryCache) item(key string) (*memoryItem, bool) {
+	item, ok := mc.items[key]
+	if !ok {
+		return nil, false
+	}
+	if !item.expireAt.IsZero() && !time.Now().Before(item.expireAt) {
+		delete(mc.items, key)
+		return nil, false
+	}
+	return item, true
+}
+
+// str 获取字符串类型的值
+func (mc *MemoryCache) str(key string) (string, bool, error) {
+	item, ok := mc.item(key)
+	if !ok {
+		return Null, false, nil
+	}
+	value, ok := item.value.(string)
+	if !ok {
+		return Null, false, ErrWrongType
+	}
+	return value, true, nil
+}
+
+// hash 获取hash 不存在且create为true时创建
+func (mc *MemoryCache) hash(key string, create bool) (map[string]string, error) {
+	if item, ok := mc.item(key); ok {
+		if value, ok := item.value.(map[string]string); ok {
+			return value, nil
+		}
+		return nil, ErrWrongType
+	}
+	if !create {
+		return nil, nil
+	}
+	value := make(map[string]string)
+	mc.items[key] = &memoryItem{value: value}
+	return value, nil
+}
+
+// list 获取list
+func (mc *MemoryCache) list(key string) ([]string, error) {
+	if item, ok := mc.item(key); ok {
+		if value, ok := item.value.([]string); ok {
+			return value, nil
+		}
+		return nil, ErrWrongType
+	}
+	return nil, nil
+}
+
+// setList 保存list 为空时删除key 与redis一致
+func (mc *MemoryCache) setList(key string, value []string) {
+	if len(value) == 0 {
+		delete(mc.items, key)
+		return
+	}
+	if item, ok := mc.item(key); ok {
+		item.value = value
+		return
+	}
+	mc.items[key] = &memoryItem{value: value}
+}
+
+// set 获取集合 不存在且create为true时创建
+func (mc *MemoryCache) set(key string, create bool) (map[string]struct{}, error) {
+	if item, ok := mc.item(key); ok {
+		if value, ok := item.value.(map[string]struct{}); ok {
+			return value, nil
+		}
+		return nil, ErrWrongType
+	}
+	if !create {
+		return nil, nil
+	}
+	value := make(map[string]struct{})
+	mc.items[key] = &memoryItem{value: value}
+	return value, nil
+}
+
+// zset 获取有序集合 不存在且create为true时创建
+func (mc *MemoryCache) zset(key string, create bool) (map[string]float64, error) {
+	if item, ok := mc.item(key); ok {
+		if value, ok := item.value.(map[string]float64); ok {
+			return value, nil
+		}
+		return nil, ErrWrongType
+	}
+	if !create {
+		return nil, nil
+	}
+	value := make(map[string]float64)
+	mc.items[key] = &memoryItem{value: value}
+	return value, nil
+}
+
+// value 与 RedisClient.GetValue 一致的序列化 再按go-redis的规则转换为字符串
+func (mc *MemoryCache) value(raw interface{}) string {
+	if raw == nil {
+		return Null
+	}
+	switch reflect.TypeOf(raw).Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Map, reflect.Array, reflect.Ptr:
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return Null
+		}
+		return string(data)
+	}
+	switch v := raw.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// values 序列化多个值
+func (mc *MemoryCache) values(raw []interface{}) []string {
+	values := make([]string, 0, len(raw))
+	for i := range raw {
+		values = append(values, mc.value(raw[i]))
+	}
+	return values
+}
+
+// Ping 总是返回true
+func (mc *MemoryCache) Ping() bool {
+	return true
+}
+
+// Expire 延期 duration不大于0时删除key 返回bool
+func (mc *MemoryCache) Expire(key string, duration time.Duration) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	item, ok := mc.item(key)
+	if !ok {
+		return mc.outcome(false, nil)
+	}
+	if duration <= 0 {
+		delete(mc.items, key)
+	} else {
+		item.expireAt = time.Now().Add(duration)
+	}
+	return mc.outcome(true, nil)
+}
+
+// Get 获取值 返回string
+func (mc *MemoryCache) Get(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	value, ok, err := mc.str(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	if !ok {
+		return mc.outcome(nil, ErrCacheMiss)
+	}
+	return mc.outcome(value, nil)
+}
+
+// GetSet 设置新值并返回旧值 会清除过期时间 返回string
+func (mc *MemoryCache) GetSet(key string, value interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	old, ok, err := mc.str(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	mc.items[key] = &memoryItem{value: mc.value(value)}
+	if !ok {
+		return mc.outcome(nil, ErrCacheMiss)
+	}
+	return mc.outcome(old, nil)
+}
+
+// Set set值 expiration不大于0时不过期 返回string
+func (mc *MemoryCache) Set(key string, value interface{}, expiration time.Duration) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.setString(key, mc.value(value), expiration)
+	return mc.outcome("OK", nil)
+}
+
+func (mc *MemoryCache) setString(key string, value string, expiration time.Duration) {
+	item := &memoryItem{value: value}
+	if expiration > 0 {
+		item.expireAt = time.Now().Add(expiration)
+	}
+	mc.items[key] = item
+}
+
+// SetNX key不存在时才set 返回bool
+func (mc *MemoryCache) SetNX(key string, value interface{}, expiration time.Duration) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if _, ok := mc.item(key); ok {
+		return mc.outcome(false, nil)
+	}
+	mc.setString(key, mc.value(value), expiration)
+	return mc.outcome(true, nil)
+}
+
+// Del 删除 返回删除的数量int64
+func (mc *MemoryCache) Del(keys ...string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := mc.item(key); ok {
+			delete(mc.items, key)
+			deleted++
+		}
+	}
+	return mc.outcome(deleted, nil)
+}
+
+// Exists 返回存在的数量int64 重复的key重复计数
+func (mc *MemoryCache) Exists(keys ...string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	var exists int64
+	for _, key := range keys {
+		if _, ok := mc.item(key); ok {
+			exists++
+		}
+	}
+	return mc.outcome(exists, nil)
+}
+
+// Decr 自减1 返回int64
+func (mc *MemoryCache) Decr(key string) *Outcome {
+	return mc.IncrBy(key, -1)
+}
+
+// DecrBy 自减 返回int64
+func (mc *MemoryCache) DecrBy(key string, decrement int64) *Outcome {
+	return mc.IncrBy(key, -decrement)
+}
+
+// Incr 自增1 返回int64
+func (mc *MemoryCache) Incr(key string) *Outcome {
+	return mc.IncrBy(key, 1)
+}
+
+// IncrBy 自增 保留原有的过期时间 返回int64
+func (mc *MemoryCache) IncrBy(key string, increment int64) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	str, ok, err := mc.str(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var value int64
+	if ok {
+		if value, err = strconv.ParseInt(str, 10, 64); err != nil {
+			return mc.outcome(nil, errNotInteger)
+		}
+	}
+	value += increment
+	if item, ok := mc.item(key); ok {
+		item.value = strconv.FormatInt(value, 10)
+	} else {
+		mc.items[key] = &memoryItem{value: strconv.FormatInt(value, 10)}
+	}
+	return mc.outcome(value, nil)
+}
+
+// MGet 批量get 返回[]interface{} 不存在或不是字符串的key对应nil
+func (mc *MemoryCache) MGet(keys ...string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	values := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if value, ok, err := mc.str(key); err == nil && ok {
+			values = append(values, value)
+		} else {
+			values = append(values, nil)
+		}
+	}
+	return mc.outcome(values, nil)
+}
+
+// MSet 批量set 参数为key value交替 返回string
+func (mc *MemoryCache) MSet(pairs ...interface{}) *Outcome {
+	if len(pairs)%2 != 0 {
+		return mc.outcome(nil, errors.New("ERR wrong number of arguments for 'mset' command"))
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for i := 0; i < len(pairs); i += 2 {
+		mc.setString(fmt.Sprint(pairs[i]), mc.value(pairs[i+1]), 0)
+	}
+	return mc.outcome("OK", nil)
+}
+
+// HGet 获取hash的值 返回string
+func (mc *MemoryCache) HGet(key string, field string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	value, ok := hash[field]
+	if !ok {
+		return mc.outcome(nil, ErrCacheMiss)
+	}
+	return mc.outcome(value, nil)
+}
+
+// HSet 给hash设置值 返回是否新增了字段bool
+func (mc *MemoryCache) HSet(key, field string, value interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, true)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	_, exists := hash[field]
+	hash[field] = mc.value(value)
+	return mc.outcome(!exists, nil)
+}
+
+// HDel 删除hash的字段 返回int64
+func (mc *MemoryCache) HDel(key string, fields ...string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var deleted int64
+	for _, field := range fields {
+		if _, ok := hash[field]; ok {
+			delete(hash, field)
+			deleted++
+		}
+	}
+	if hash != nil && len(hash) == 0 {
+		delete(mc.items, key)
+	}
+	return mc.outcome(deleted, nil)
+}
+
+// HExists 判断hash的字段是否存在 返回bool
+func (mc *MemoryCache) HExists(key string, field string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	_, ok := hash[field]
+	return mc.outcome(ok, nil)
+}
+
+// HGetAll 获取hash的所有值 返回map[string]string 的拷贝
+func (mc *MemoryCache) HGetAll(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	values := make(map[string]string, len(hash))
+	for field, value := range hash {
+		values[field] = value
+	}
+	return mc.outcome(values, nil)
+}
+
+// HKeys 获取hash的所有字段 返回排好序的[]string
+func (mc *MemoryCache) HKeys(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return mc.outcome(fields, nil)
+}
+
+// HLen 获取hash的长度 返回int64
+func (mc *MemoryCache) HLen(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	return mc.outcome(int64(len(hash)), nil)
+}
+
+// HIncrBy hash的字段自增 返回int64
+func (mc *MemoryCache) HIncrBy(key string, field string, incr int64) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, true)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var value int64
+	if str, ok := hash[field]; ok {
+		if value, err = strconv.ParseInt(str, 10, 64); err != nil {
+			return mc.outcome(nil, errNotInteger)
+		}
+	}
+	value += incr
+	hash[field] = strconv.FormatInt(value, 10)
+	return mc.outcome(value, nil)
+}
+
+// HIncrByFloat hash的字段按浮点数自增 返回float64
+func (mc *MemoryCache) HIncrByFloat(key, field string, incr float64) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	hash, err := mc.hash(key, true)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var value float64
+	if str, ok := hash[field]; ok {
+		if value, err = strconv.ParseFloat(str, 64); err != nil {
+			return mc.outcome(nil, errNotFloat)
+		}
+	}
+	value += incr
+	hash[field] = strconv.FormatFloat(value, 'f', -1, 64)
+	return mc.outcome(value, nil)
+}
+
+// LPush 从左侧插入 返回int64
+func (mc *MemoryCache) LPush(key string, values ...interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	list, err := mc.list(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	pushed := make([]string, 0, len(list)+len(values))
+	for _, value := range mc.values(values) {
+		pushed = append([]string{value}, pushed...)
+	}
+	list = append(pushed, list...)
+	mc.setList(key, list)
+	return mc.outcome(int64(len(list)), nil)
+}
+
+// RPush 从右侧插入 返回int64
+func (mc *MemoryCache) RPush(key string, values ...interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	list, err := mc.list(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	list = append(list, mc.values(values)...)
+	mc.setList(key, list)
+	return mc.outcome(int64(len(list)), nil)
+}
+
+// LPop 从左侧弹出 返回string
+func (mc *MemoryCache) LPop(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	list, err := mc.list(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	if len(list) == 0 {
+		return mc.outcome(nil, ErrCacheMiss)
+	}
+	mc.setList(key, list[1:])
+	return mc.outcome(list[0], nil)
+}
+
+// RPop 从右侧弹出 返回string
+func (mc *MemoryCache) RPop(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	list, err := mc.list(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	if len(list) == 0 {
+		return mc.outcome(nil, ErrCacheMiss)
+	}
+	mc.setList(key, list[:len(list)-1])
+	return mc.outcome(list[len(list)-1], nil)
+}
+
+// LLen 获取list的长度 返回int64
+func (mc *MemoryCache) LLen(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	list, err := mc.list(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	return mc.outcome(int64(len(list)), nil)
+}
+
+// LRange 获取区间内的元素 支持负数下标 返回[]string
+func (mc *MemoryCache) LRange(key string, start, stop int64) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	list, err := mc.list(key)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	from, to := memoryRange(len(list), start, stop)
+	values := make([]string, to-from)
+	copy(values, list[from:to])
+	return mc.outcome(values, nil)
+}
+
+// SAdd 添加集合成员 返回新增的数量int64
+func (mc *MemoryCache) SAdd(key string, members ...interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	set, err := mc.set(key, true)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var added int64
+	for _, member := range mc.values(members) {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			added++
+		}
+	}
+	return mc.outcome(added, nil)
+}
+
+// SRem 删除集合成员 返回int64
+func (mc *MemoryCache) SRem(key string, members ...interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	set, err := mc.set(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var removed int64
+	for _, member := range mc.values(members) {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			removed++
+		}
+	}
+	if set != nil && len(set) == 0 {
+		delete(mc.items, key)
+	}
+	return mc.outcome(removed, nil)
+}
+
+// SMembers 获取集合所有成员 返回排好序的[]string
+func (mc *MemoryCache) SMembers(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	set, err := mc.set(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return mc.outcome(members, nil)
+}
+
+// SIsMember 判断是否为集合成员 返回bool
+func (mc *MemoryCache) SIsMember(key string, member interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	set, err := mc.set(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	_, ok := set[mc.value(member)]
+	return mc.outcome(ok, nil)
+}
+
+// SCard 获取集合的成员数 返回int64
+func (mc *MemoryCache) SCard(key string) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	set, err := mc.set(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	return mc.outcome(int64(len(set)), nil)
+}
+
+// ZAdd 添加有序集合成员 已存在的成员更新分数 返回新增的数量int64
+func (mc *MemoryCache) ZAdd(key string, members ...redis.Z) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	zset, err := mc.zset(key, true)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var added int64
+	for i := range members {
+		member := mc.value(members[i].Member)
+		if _, ok := zset[member]; !ok {
+			added++
+		}
+		zset[member] = members[i].Score
+	}
+	return mc.outcome(added, nil)
+}
+
+// ZRem 删除有序集合成员 返回int64
+func (mc *MemoryCache) ZRem(key string, members ...interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	zset, err := mc.zset(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	var removed int64
+	for _, member := range mc.values(members) {
+		if _, ok := zset[member]; ok {
+			delete(zset, member)
+			removed++
+		}
+	}
+	if zset != nil && len(zset) == 0 {
+		delete(mc.items, key)
+	}
+	return mc.outcome(removed, nil)
+}
+
+// ZRange 按排名获取成员 返回[]string
+func (mc *MemoryCache) ZRange(key string, start, stop int64) *Outcome {
+	outcome := mc.ZRangeWithScores(key, start, stop)
+	if outcome.Error != nil {
+		return outcome
+	}
+	zs := outcome.Primordial.([]redis.Z)
+	members := make([]string, 0, len(zs))
+	for i := range zs {
+		members = append(members, zs[i].Member.(string))
+	}
+	return mc.outcome(members, nil)
+}
+
+// ZRangeWithScores 按排名获取成员和分数 返回[]redis.Z
+func (mc *MemoryCache) ZRangeWithScores(key string, start, stop int64) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	zset, err := mc.zset(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	zs := sortedZ(zset)
+	from, to := memoryRange(len(zs), start, stop)
+	return mc.outcome(zs[from:to], nil)
+}
+
+// ZRangeByScore 按分数获取成员 支持 ( 开区间和 -inf/+inf 返回[]string
+func (mc *MemoryCache) ZRangeByScore(key string, opt *redis.ZRangeBy) *Outcome {
+	min, minOpen, err := parseScoreBound(opt.Min)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	max, maxOpen, err := parseScoreBound(opt.Max)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	zset, err := mc.zset(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	members := make([]string, 0)
+	for _, z := range sortedZ(zset) {
+		if z.Score < min || (minOpen && z.Score == min) || z.Score > max || (maxOpen && z.Score == max) {
+			continue
+		}
+		members = append(members, z.Member.(string))
+	}
+	if opt.Offset > 0 || opt.Count > 0 {
+		if opt.Offset >= int64(len(members)) {
+			members = members[:0]
+		} else {
+			members = members[opt.Offset:]
+		}
+		if opt.Count > 0 && opt.Count < int64(len(members)) {
+			members = members[:opt.Count]
+		}
+	}
+	return mc.outcome(members, nil)
+}
+
+// ZScore 获取成员的分数 返回float64
+func (mc *MemoryCache) ZScore(key string, member interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	zset, err := mc.zset(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	score, ok := zset[mc.value(member)]
+	if !ok {
+		return mc.outcome(nil, ErrCacheMiss)
+	}
+	return mc.outcome(score, nil)
+}
+
+// ZRank 获取成员的排名 返回int64
+func (mc *MemoryCache) ZRank(key string, member interface{}) *Outcome {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	zset, err := mc.zset(key, false)
+	if err != nil {
+		return mc.outcome(nil, err)
+	}
+	target := mc.value(member)
+	for i, z := range sortedZ(zset) {
+		if z.Member.(string) == target {
+			return mc.outcome(int64(i), nil)
+		}
+	}
+	return mc.outcome(nil, ErrCacheMiss)
+}
+
+// sortedZ 按分数排序 分数相同时按成员的字典序 与redis一致
+func sortedZ(zset map[string]float64) []redis.Z {
+	zs := make([]redis.Z, 0, len(zset))
+	for member, score := range zset {
+		zs = append(zs, redis.Z{Score: score, Member: member})
+	}
+	sort.Slice(zs, func(i, j int) bool {
+		if zs[i].Score != zs[j].Score {
+			return zs[i].Score < zs[j].Score
+		}
+		return zs[i].Member.(string) < zs[j].Member.(string)
+	})
+	return zs
+}
+
+// memoryRange 将redis的闭区间下标转换为切片的区间
+func memoryRange(length int, start, stop int64) (int, int) {
+	n := int64(length)
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop || start >= n {
+		return 0, 0
+	}
+	return int(start), int(stop + 1)
+}
+
+// parseScoreBound 解析分数区间 ( 开头表示开区间
+func parseScoreBound(raw string) (float64, bool, error) {
+	open := strings.HasPrefix(raw, "(")
+	raw = strings.TrimPrefix(raw, "(")
+	switch strings.ToLower(raw) {
+	case "-inf":
+		return math.Inf(-1), open, nil
+	case "+inf", "inf":
+		return math.Inf(1), open, nil
+	}
+	score, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, errors.New("ERR min or max is not a float")
+	}
+	return score, open, nil
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// expireNow 将key的过期时间改到过去 不依赖sleep
+func expireNow(mc *MemoryCache, key string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if item, ok := mc.items[key]; ok {
+		item.expireAt = time.Now().Add(-time.Millisecond)
+	}
+}
+
+func TestMemoryCacheSetGet(t *testing.T) {
+	mc := NewMemoryCache()
+	if err := mc.Set("s", "v", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, err := mc.Get("s").GetString(); err != nil || got != "v" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	mc.Set("u", testUser{Name: "a", Age: 1}, 0)
+	var u testUser
+	if err := mc.Get("u").Unmarshal(&u); err != nil || u.Name != "a" || u.Age != 1 {
+		t.Fatalf("Unmarshal = %+v, %v", u, err)
+	}
+	if oc := mc.Get("missing"); !oc.IsNil() || !errors.Is(oc.Error, ErrCacheMiss) {
+		t.Fatalf("missing key should be a miss, got %v", oc.Error)
+	}
+	if ok, _ := mc.SetNX("s", "other", 0).GetBool(); ok {
+		t.Fatal("SetNX on an existing key should fail")
+	}
+	if n, _ := mc.Del("s", "missing").GetInt64(); n != 1 {
+		t.Fatalf("Del = %d", n)
+	}
+}
+
+func TestMemoryCacheTTLExpiry(t *testing.T) {
+	mc := NewMemoryCache()
+	mc.Set("k", "v", time.Minute)
+	mc.Set("forever", "v", 0)
+	expireNow(mc, "k")
+	if !mc.Get("k").IsNil() {
+		t.Fatal("expired key should be a miss")
+	}
+	if n, _ := mc.Exists("k", "forever").GetInt64(); n != 1 {
+		t.Fatalf("Exists after expiry = %d", n)
+	}
+
+	mc.Set("short", "v", 20*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	if !mc.Get("short").IsNil() {
+		t.Fatal("key should expire after its ttl")
+	}
+}
+
+func TestMemoryCacheExpire(t *testing.T) {
+	mc := NewMemoryCache()
+	if ok, _ := mc.Expire("missing", time.Minute).GetBool(); ok {
+		t.Fatal("Expire on a missing key should return false")
+	}
+	mc.Set("k", "v", 0)
+	if ok, _ := mc.Expire("k", time.Minute).GetBool(); !ok {
+		t.Fatal("Expire should return true")
+	}
+	expireNow(mc, "k")
+	if !mc.Get("k").IsNil() {
+		t.Fatal("key should expire after Expire")
+	}
+	mc.Set("k", "v", 0)
+	mc.Expire("k", 0)
+	if !mc.Get("k").IsNil() {
+		t.Fatal("Expire with zero duration should delete the key")
+	}
+}
+
+func TestMemoryCacheIncr(t *testing.T) {
+	mc := NewMemoryCache()
+	if n, err := mc.Incr("n").GetInt64(); err != nil || n != 1 {
+		t.Fatalf("Incr = %d, %v", n, err)
+	}
+	if n, _ := mc.IncrBy("n", 10).GetInt64(); n != 11 {
+		t.Fatalf("IncrBy = %d", n)
+	}
+	if n, _ := mc.DecrBy("n", 4).GetInt64(); n != 7 {
+		t.Fatalf("DecrBy = %d", n)
+	}
+	if n, _ := mc.Decr("n").GetInt64(); n != 6 {
+		t.Fatalf("Decr = %d", n)
+	}
+	if got, _ := mc.Get("n").GetString(); got != "6" {
+		t.Fatalf("counter stored as %q", got)
+	}
+
+	mc.Set("ttl", 1, time.Minute)
+	mc.Incr("ttl")
+	expireNow(mc, "ttl")
+	if !mc.Get("ttl").IsNil() {
+		t.Fatal("Incr should keep the existing expiry")
+	}
+
+	mc.Set("s", "abc", 0)
+	if err := mc.Incr("s").Error; !errors.Is(err, errNotInteger) {
+		t.Fatalf("Incr on a non-integer: %v", err)
+	}
+	mc.HSet("h", "f", "v")
+	if err := mc.Incr("h").Error; !errors.Is(err, ErrWrongType) {
+		t.Fatalf("Incr on a hash: %v", err)
+	}
+}
+
+func TestMemoryCacheHash(t *testing.T) {
+	mc := NewMemoryCache()
+	if added, _ := mc.HSet("h", "f", "v1").GetBool(); !added {
+		t.Fatal("HSet of a new field should return true")
+	}
+	if added, _ := mc.HSet("h", "f", "v2").GetBool(); added {
+		t.Fatal("HSet of an existing field should return false")
+	}
+	if got, _ := mc.HGet("h", "f").GetString(); got != "v2" {
+		t.Fatalf("HGet = %q", got)
+	}
+	if !mc.HGet("h", "missing").IsNil() {
+		t.Fatal("missing field should be a miss")
+	}
+	mc.HSet("h", "u", testUser{Name: "a"})
+	all, err := mc.HGetAll("h").GetMap()
+	if err != nil || len(all) != 2 || all["f"] != "v2" || !strings.Contains(all["u"], `"name":"a"`) {
+		t.Fatalf("HGetAll = %v, %v", all, err)
+	}
+	if n, _ := mc.HIncrBy("h", "n", 3).GetInt64(); n != 3 {
+		t.Fatalf("HIncrBy = %d", n)
+	}
+	if n, _ := mc.HDel("h", "f", "missing").GetInt64(); n != 1 {
+		t.Fatalf("HDel = %d", n)
+	}
+	if n, _ := mc.HLen("h").GetInt64(); n != 2 {
+		t.Fatalf("HLen = %d", n)
+	}
+	mc.Set("s", "v", 0)
+	if err := mc.HSet("s", "f", "v").Error; !errors.Is(err, ErrWrongType) {
+		t.Fatalf("HSet on a string: %v", err)
+	}
+}
+
+func TestMemoryCacheLPushOrder(t *testing.T) {
+	mc := NewMemoryCache()
+	mc.RPush("l", "x")
+	if n, _ := mc.LPush("l", "a", "b", "c").GetInt64(); n != 4 {
+		t.Fatalf("LPush len = %d", n)
+	}
+	// 与redis一致 LPush a b c 后从左到右为 c b a
+	if got, _ := mc.LRange("l", 0, -1).GetArray(); strings.Join(got, ",") != "c,b,a,x" {
+		t.Fatalf("LRange = %v", got)
+	}
+	if got, _ := mc.LPop("l").GetString(); got != "c" {
+		t.Fatalf("LPop = %q", got)
+	}
+	if got, _ := mc.RPop("l").GetString(); got != "x" {
+		t.Fatalf("RPop = %q", got)
+	}
+	mc.LPop("l")
+	mc.LPop("l")
+	if n, _ := mc.Exists("l").GetInt64(); n != 0 {
+		t.Fatal("an emptied list should be deleted")
+	}
+}
+
+func TestMemoryRange(t *testing.T) {
+	cases := []struct {
+		length      int
+		start, stop int64
+		from, to    int
+	}{
+		{5, 0, -1, 0, 5},
+		{5, 1, 2, 1, 3},
+		{5, -2, -1, 3, 5},
+		{5, -10, 1, 0, 2},
+		{5, 2, 100, 2, 5},
+		{5, 3, 1, 0, 0},
+		{5, 5, 10, 0, 0},
+		{0, 0, -1, 0, 0},
+	}
+	for _, c := range cases {
+		from, to := memoryRange(c.length, c.start, c.stop)
+		if from != c.from || to != c.to {
+			t.Errorf("memoryRange(%d, %d, %d) = %d, %d; want %d, %d",
+				c.length, c.start, c.stop, from, to, c.from, c.to)
+		}
+	}
+}
+
+func TestParseScoreBound(t *testing.T) {
+	cases := []struct {
+		raw   string
+		score float64
+		open  bool
+	}{
+		{"1.5", 1.5, false},
+		{"(2", 2, true},
+		{"-inf", math.Inf(-1), false},
+		{"+inf", math.Inf(1), false},
+		{"(+inf", math.Inf(1), true},
+	}
+	for _, c := range cases {
+		score, open, err := parseScoreBound(c.raw)
+		if err != nil || score != c.score || open != c.open {
+			t.Errorf("parseScoreBound(%q) = %v, %v, %v", c.raw, score, open, err)
+		}
+	}
+	if _, _, err := parseScoreBound("abc"); err == nil {
+		t.Fatal("a non-float bound should fail")
+	}
+}
+
+func TestMemoryCacheZRangeByScore(t *testing.T) {
+	mc := NewMemoryCache()
+	mc.ZAdd("z", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}, redis.Z{Score: 3, Member: "c"})
+	got, err := mc.ZRangeByScore("z", &redis.ZRangeBy{Min: "(1", Max: "+inf"}).GetArray()
+	if err != nil || strings.Join(got, ",") != "b,c" {
+		t.Fatalf("ZRangeByScore = %v, %v", got, err)
+	}
+	if rank, _ := mc.ZRank("z", "c").GetInt64(); rank != 2 {
+		t.Fatalf("ZRank = %d", rank)
+	}
+}
+
+func TestMemoryCacheConcurrent(t *testing.T) {
+	mc := NewMemoryCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mc.Incr("n")
+			mc.HIncrBy("h", "f", 1)
+		}()
+	}
+	wg.Wait()
+	if n, _ := mc.Get("n").GetInt64(); n != 50 {
+		t.Fatalf("concurrent Incr = %d", n)
+	}
+	if got, _ := mc.HGet("h", "f").GetString(); got != "50" {
+		t.Fatalf("concurrent HIncrBy = %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// MigrationSource 迁移期间的读取来源
+type MigrationSource int32
+
+const (
+	// ReadFromOld 从旧集群读取
+	ReadFromOld MigrationSource = iota
+	// ReadFromNew 从新集群读取
+	ReadFromNew
+)
+
+// MigrationClient 在两个redis部署之间迁移时使用 写操作同时写入新旧两边 读操作只读配置的来源
+type MigrationClient struct {
+	from   *RedisClient
+	to     *RedisClient
+	source int32
+}
+
+// NewMigrationClient 实例化迁移客户端 from为旧部署 to为新部署 不负责关闭传入的客户端
+func NewMigrationClient(from, to *RedisClient, source MigrationSource) *MigrationClient {
+	return &MigrationClient{from: from, to: to, source: int32(source)}
+}
+
+// SetSource 切换读取来源 可以在运行中切换
+func (mc *MigrationClient) SetSource(source MigrationSource) {
+	atomic.StoreInt32(&mc.source, int32(source))
+}
+
+// Source 当前的读取来源
+func (mc *MigrationClient) Source() MigrationSource {
+	return MigrationSource(atomic.LoadInt32(&mc.source))
+}
+
+// Reader 当前读取来源对应的客户端
+func (mc *MigrationClient) Reader() *RedisClient {
+	if mc.Source() == ReadFromNew {
+		return mc.to
+	}
+	return mc.from
+}
+
+// Get 从读取来源获取值 返回string
+func (mc *MigrationClient) Get(key string) *Outcome {
+	return mc.Reader().Get(key)
+}
+
+// HGet 从读取来源获取hash的值 返回string
+func (mc *MigrationClient) HGet(key string, field string) *Outcome {
+	return mc.Reader().HGet(key, field)
+}
+
+// HGetAll 从读取来源获取hash的所有值 返回map[string]string
+func (mc *MigrationClient) HGetAll(key string) *Outcome {
+	return mc.Reader().HGetAll(key)
+}
+
+// Set 同时写入新旧两边 返回string
+func (mc *MigrationClient) Set(key string, value interface{}, expiration time.Duration) *Outcome {
+	return mc.dual(func(rc *RedisClient) *Outcome {
+		return rc.Set(key, value, expiration)
+	})
+}
+
+// Del 同时删除新旧两边 返回读取来源删除的数量int64
+func (mc *MigrationClient) Del(keys ...string) *Outcome {
+	return mc.dual(func(rc *RedisClient) *Outcome {
+		return rc.Del(keys...)
+	})
+}
+
+// HSet 同时写入新旧两边的hash 返回bool
+func (mc *MigrationClient) HSet(key, field string, value interface{}) *Outcome {
+	return mc.dual(func(rc *RedisClient) *Outcome {
+		return rc.HSet(key, field, value)
+	})
+}
+
+// dual 在两边执行写操作 读取来源失败时返回其结果 否则另一边失败时返回另一边的结果
+func (mc *MigrationClient) dual(write func(rc *RedisClient) *Outcome) *Outcome {
+	primary, secondary := mc.from, mc.to
+	if mc.Source() == ReadFromNew {
+		primary, secondary = mc.to, mc.from
+	}
+	outcome := write(primary)
+	if outcome.Error != nil {
+		return outcome
+	}
+	if other := write(secondary); other.Error != nil {
+		return other
+	}
+	return outcome
+}
@@ -0,0 +1,60 @@
+package cache
+
+import "testing"
+
+func TestMigrationDualWritesAndReadsFromSource(t *testing.T) {
+	from, oldServer := newTestClient(t)
+	to, newServer := newTestClient(t)
+	mc := NewMigrationClient(from, to, ReadFromOld)
+
+	if err := mc.Set("k", "v", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := mc.HSet("h", "f", "v").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, mr := range []interface {
+		Get(string) (string, error)
+		HGet(string, string) string
+	}{oldServer, newServer} {
+		if got, _ := mr.Get("app:test:k"); got != "v" {
+			t.Fatalf("Set did not reach both backends, got %q", got)
+		}
+		if got := mr.HGet("app:test:h", "f"); got != "v" {
+			t.Fatalf("HSet did not reach both backends, got %q", got)
+		}
+	}
+
+	oldServer.Set("app:test:k", "old")
+	newServer.Set("app:test:k", "new")
+	if got, _ := mc.Get("k").GetString(); got != "old" {
+		t.Fatalf("ReadFromOld Get = %q", got)
+	}
+	mc.SetSource(ReadFromNew)
+	if got, _ := mc.Get("k").GetString(); got != "new" || mc.Reader() != to {
+		t.Fatalf("ReadFromNew Get = %q", got)
+	}
+	if fields, _ := mc.HGetAll("h").GetMap(); fields["f"] != "v" {
+		t.Fatalf("HGetAll = %v", fields)
+	}
+
+	if err := mc.Del("k").Error; err != nil {
+		t.Fatal(err)
+	}
+	if oldServer.Exists("app:test:k") || newServer.Exists("app:test:k") {
+		t.Fatal("Del did not reach both backends")
+	}
+}
+
+func TestMigrationReportsSecondaryFailure(t *testing.T) {
+	from, _ := newTestClient(t)
+	to, newServer := newTestClient(t, func(o *Options) { o.MaxRetries = -1 })
+	mc := NewMigrationClient(from, to, ReadFromOld)
+	newServer.Close()
+	if err := mc.Set("k", "v", 0).Error; err == nil {
+		t.Fatal("a failed write to the new backend should be reported")
+	}
+	if got, _ := mc.Get("k").GetString(); got != "v" {
+		t.Fatalf("primary write should still land, got %q", got)
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import "time"
+
+// must 命令出错或类型不匹配时panic 只建议在脚本和测试中使用
+func (oc *Outcome) must(err error) {
+	if oc.Error != nil {
+		panic(oc.Error)
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+// MustInt64 同 GetInt64 出错时panic
+func (oc *Outcome) MustInt64() int64 {
+	v, err := oc.GetInt64()
+	oc.must(err)
+	return v
+}
+
+// MustInt 同 GetInt 出错时panic
+func (oc *Outcome) MustInt() int {
+	v, err := oc.GetInt()
+	oc.must(err)
+	return v
+}
+
+// MustUint64 同 GetUint64 出错时panic
+func (oc *Outcome) MustUint64() uint64 {
+	v, err := oc.GetUint64()
+	oc.must(err)
+	return v
+}
+
+// MustString 同 GetString 出错时panic
+func (oc *Outcome) MustString() string {
+	v, err := oc.GetString()
+	oc.must(err)
+	return v
+}
+
+// MustBytes 同 GetBytes 出错时panic
+func (oc *Outcome) MustBytes() []byte {
+	v, err := oc.GetBytes()
+	oc.must(err)
+	return v
+}
+
+// MustFloat64 同 GetFloat64 出错时panic
+func (oc *Outcome) MustFloat64() float64 {
+	v, err := oc.GetFloat64()
+	oc.must(err)
+	return v
+}
+
+// MustBool 同 GetBool 出错时panic
+func (oc *Outcome) MustBool() bool {
+	v, err := oc.GetBool()
+	oc.must(err)
+	return v
+}
+
+// MustMap 同 GetMap 出错时panic
+func (oc *Outcome) MustMap() map[string]string {
+	v, err := oc.GetMap()
+	oc.must(err)
+	return v
+}
+
+// MustArray 同 GetArray 出错时panic
+func (oc *Outcome) MustArray() []string {
+	v, err := oc.GetArray()
+	oc.must(err)
+	return v
+}
+
+// MustDuration 同 GetDuration 出错时panic
+func (oc *Outcome) MustDuration() time.Duration {
+	v, err := oc.GetDuration()
+	oc.must(err)
+	return v
+}
+
+// MustUnmarshal 同 Unmarshal 出错时panic
+func (oc *Outcome) MustUnmarshal(v interface{}) {
+	oc.must(oc.Unmarshal(v))
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+)
+
+func mustPanic(t *testing.T, name string, f func()) (recovered interface{}) {
+	t.Helper()
+	defer func() {
+		recovered = recover()
+		if recovered == nil {
+			t.Errorf("%s should panic", name)
+		}
+	}()
+	f()
+	return nil
+}
+
+func TestMustHelpersReturnValues(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("n", 42, 0)
+	rc.Set("s", "hello", 0)
+	rc.Set("u", testUser{Name: "a", Age: 3}, 0)
+	if got := rc.Get("n").MustInt64(); got != 42 {
+		t.Fatalf("MustInt64 = %d", got)
+	}
+	if got := rc.Get("n").MustInt(); got != 42 {
+		t.Fatalf("MustInt = %d", got)
+	}
+	if got := rc.Get("s").MustString(); got != "hello" {
+		t.Fatalf("MustString = %q", got)
+	}
+	var u testUser
+	rc.Get("u").MustUnmarshal(&u)
+	if u.Name != "a" || u.Age != 3 {
+		t.Fatalf("MustUnmarshal = %+v", u)
+	}
+}
+
+func TestMustHelpersPanic(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("s", "hello", 0)
+	mustPanic(t, "MustInt64 on a non-number", func() { rc.Get("s").MustInt64() })
+	mustPanic(t, "MustMap on a string", func() { rc.Get("s").MustMap() })
+	p := mustPanic(t, "MustString on a miss", func() { rc.Get("missing").MustString() })
+	if err, ok := p.(error); !ok || !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("miss should panic with ErrCacheMiss, got %v", p)
+	}
+}
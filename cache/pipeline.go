@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// Pipeliner 管道 排队的命令在 Exec 时一次往返提交
+type Pipeliner struct {
+	rc       *RedisClient
+	pipe     redis.Pipeliner
+	outcomes []func() *Outcome
+	// written 排队的写命令 Exec 后使本地缓存失效
+	written []localWrite
+}
+
+// Pipeline 获取一个管道 key和value的处理与 RedisClient 一致
+func (rc *RedisClient) Pipeline() *Pipeliner {
+	return &Pipeliner{
+		rc:   rc,
+		pipe: rc.Runner().Pipeline(),
+	}
+}
+
+// queue 记录命令执行后如何生成统一返回值
+func (p *Pipeliner) queue(outcome func() *Outcome) *Pipeliner {
+	p.outcomes = append(p.outcomes, outcome)
+	return p
+}
+
+// Len 已排队的命令数量
+func (p *Pipeliner) Len() int {
+	return len(p.outcomes)
+}
+
+// Exec 提交所有命令 返回值与排队顺序一一对应
+func (p *Pipeliner) Exec() []*Outcome {
+	_, _ = p.pipe.Exec(p.rc.ctx)
+	p.rc.invalidateWrites(p.written)
+	p.written = nil
+	outcomes := make([]*Outcome, 0, len(p.outcomes))
+	for i := range p.outcomes {
+		outcomes = append(outcomes, p.outcomes[i]())
+	}
+	p.outcomes = nil
+	return outcomes
+}
+
+// Discard 丢弃所有排队的命令
+func (p *Pipeliner) Discard() error {
+	p.outcomes = nil
+	p.written = nil
+	return p.pipe.Discard()
+}
+
+// Expire 延期 返回bool
+func (p *Pipeliner) Expire(key string, duration time.Duration) *Pipeliner {
+	cmd := p.pipe.Expire(p.rc.ctx, p.rc.GetKey(key), duration)
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// Get 获取值 返回string
+func (p *Pipeliner) Get(key string) *Pipeliner {
+	cmd := p.pipe.Get(p.rc.ctx, p.rc.GetKey(key))
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// Set set值 返回string
+func (p *Pipeliner) Set(key string, value interface{}, expiration time.Duration) *Pipeliner {
+	cmd := p.pipe.Set(p.rc.ctx, p.rc.GetKey(key), p.rc.GetValue(value), p.rc.Drift(expiration))
+	p.written = append(p.written, localWrite{keys: []string{key}, cmd: cmd})
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// SetNX setNx 返回bool
+func (p *Pipeliner) SetNX(key string, value interface{}, expiration time.Duration) *Pipeliner {
+	cmd := p.pipe.SetNX(p.rc.ctx, p.rc.GetKey(key), p.rc.GetValue(value), p.rc.Drift(expiration))
+	p.written = append(p.written, localWrite{keys: []string{key}, cmd: cmd})
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// Del 删除key 返回int64
+func (p *Pipeliner) Del(keys ...string) *Pipeliner {
+	cmd := p.pipe.Del(p.rc.ctx, p.rc.getHooks(keys)...)
+	p.written = append(p.written, localWrite{keys: keys, cmd: cmd})
+	return p.queue(func() *Outcome { return p.rc.keysOutcome(keys, cmd.Val(), cmd) })
+}
+
+// Incr 自增1 返回int64
+func (p *Pipeliner) Incr(key string) *Pipeliner {
+	cmd := p.pipe.Incr(p.rc.ctx, p.rc.GetKey(key))
+	p.written = append(p.written, localWrite{keys: []string{key}, cmd: cmd})
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// IncrBy 自增多 返回int64
+func (p *Pipeliner) IncrBy(key string, increment int64) *Pipeliner {
+	cmd := p.pipe.IncrBy(p.rc.ctx, p.rc.GetKey(key), increment)
+	p.written = append(p.written, localWrite{keys: []string{key}, cmd: cmd})
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// HGet 获取hash的值 返回string
+func (p *Pipeliner) HGet(key string, field string) *Pipeliner {
+	cmd := p.pipe.HGet(p.rc.ctx, p.rc.GetKey(key), field)
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// HSet 给hash设置值 返回bool
+func (p *Pipeliner) HSet(key, field string, value interface{}) *Pipeliner {
+	cmd := p.pipe.HSet(p.rc.ctx, p.rc.GetKey(key), field, p.rc.GetValue(value))
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val() > 0, cmd) })
+}
+
+// HDel 删除hash的key 返回int64
+func (p *Pipeliner) HDel(key string, fields ...string) *Pipeliner {
+	cmd := p.pipe.HDel(p.rc.ctx, p.rc.GetKey(key), fields...)
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
+
+// HGetAll 获取hash的所有值 返回map[string]string
+func (p *Pipeliner) HGetAll(key string) *Pipeliner {
+	cmd := p.pipe.HGetAll(p.rc.ctx, p.rc.GetKey(key))
+	return p.queue(func() *Outcome { return p.rc.keyOutcome(key, cmd.Val(), cmd) })
+}
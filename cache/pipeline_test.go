@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// countingHook 统计发往服务器的命令和管道往返次数
+type countingHook struct {
+	mu        sync.Mutex
+	commands  map[string]int
+	pipelines int
+}
+
+func newCountingHook(rc *RedisClient) *countingHook {
+	h := &countingHook{commands: make(map[string]int)}
+	rc.addHook(h)
+	return h
+}
+
+func (h *countingHook) count(name string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.commands[name]
+}
+
+func (h *countingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.mu.Lock()
+	h.commands[cmd.Name()]++
+	h.mu.Unlock()
+	return ctx, nil
+}
+
+func (h *countingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *countingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	h.mu.Lock()
+	h.pipelines++
+	for _, cmd := range cmds {
+		h.commands[cmd.Name()]++
+	}
+	h.mu.Unlock()
+	return ctx, nil
+}
+
+func (h *countingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestPipelineBatchesInOneExec(t *testing.T) {
+	rc, mr := newTestClient(t)
+	hook := newCountingHook(rc)
+	pipe := rc.Pipeline()
+	for i := 0; i < 100; i++ {
+		pipe.Set(fmt.Sprintf("k%d", i), i, 0)
+	}
+	pipe.Get("k7").Get("missing")
+	if pipe.Len() != 102 {
+		t.Fatalf("queued %d commands", pipe.Len())
+	}
+	outcomes := pipe.Exec()
+	if hook.pipelines != 1 {
+		t.Fatalf("%d round trips, want 1", hook.pipelines)
+	}
+	if len(outcomes) != 102 {
+		t.Fatalf("%d outcomes", len(outcomes))
+	}
+	for i := 0; i < 100; i++ {
+		if s, err := outcomes[i].GetString(); err != nil || s != "OK" {
+			t.Fatalf("outcome %d: %q, %v", i, s, err)
+		}
+		if got, _ := mr.Get(fmt.Sprintf("app:test:k%d", i)); got != fmt.Sprint(i) {
+			t.Fatalf("k%d = %q", i, got)
+		}
+	}
+	if n, _ := outcomes[100].GetInt(); n != 7 {
+		t.Fatalf("queued get returned %d", n)
+	}
+	if !outcomes[101].IsNil() {
+		t.Fatal("queued get on a missing key should be a miss")
+	}
+}
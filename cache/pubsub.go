@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"sync"
+)
+
+// Message 订阅收到的消息 内容通过 Outcome 的方法解析
+// Channel 为去掉统一前缀的频道名
+type Message struct {
+	*Outcome
+	Channel string
+	Pattern string
+}
+
+// Subscription 订阅 通过 Channel 接收消息 不再使用时需要 Close
+type Subscription struct {
+	pubsub     *redis.PubSub
+	prefix     string
+	codec      Codec
+	decompress bool
+	messages   chan *Message
+	done       chan struct{}
+	once       sync.Once
+}
+
+// Publish 发布消息 消息会自动序列化 返回收到消息的订阅者数量int64
+func (rc *RedisClient) Publish(channel string, message interface{}) *Outcome {
+	hook := rc.GetKey(channel)
+	cmd := rc.Runner().Publish(rc.ctx, hook, rc.GetValue(message))
+	return rc.keyOutcome(channel, cmd.Val(), cmd)
+}
+
+// Subscribe 订阅频道 频道名会自动加上统一前缀
+// 集群模式下普通的发布订阅会在整个集群内广播 任意节点订阅即可
+func (rc *RedisClient) Subscribe(channels ...string) (*Subscription, error) {
+	return rc.subscription(rc.subscribe(rc.getHooks(channels)...))
+}
+
+// PSubscribe 按glob模式订阅频道 模式会自动加上转义后的统一前缀 只匹配当前命名空间下的频道
+// 收到的消息中 Channel 为实际的频道名 Pattern 为匹配到的模式 均已去掉统一前缀
+func (rc *RedisClient) PSubscribe(patterns ...string) (*Subscription, error) {
+	prefix := escapePattern(rc.GetKey(Null))
+	hooks := make([]string, 0, len(patterns))
+	for i := range patterns {
+		hooks = append(hooks, prefix+patterns[i])
+	}
+	var pubsub *redis.PubSub
+	if rc.flag {
+		pubsub = rc.single.PSubscribe(rc.ctx, hooks...)
+	} else {
+		pubsub = rc.cluster.PSubscribe(rc.ctx, hooks...)
+	}
+	return rc.subscription(pubsub)
+}
+
+// subscribe 订阅完整频道名 不等待订阅确认
+func (rc *RedisClient) subscribe(hooks ...string) *redis.PubSub {
+	if rc.flag {
+		return rc.single.Subscribe(rc.ctx, hooks...)
+	}
+	return rc.cluster.Subscribe(rc.ctx, hooks...)
+}
+
+// subscription 等待订阅确认后开始转发消息
+func (rc *RedisClient) subscription(pubsub *redis.PubSub) (*Subscription, error) {
+	if _, err := pubsub.Receive(rc.ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	sub := &Subscription{
+		pubsub:     pubsub,
+		prefix:     rc.GetKey(Null),
+		codec:      rc.codec(),
+		decompress: rc.opt.Compression != CompressionNone,
+		messages:   make(chan *Message),
+		done:       make(chan struct{}),
+	}
+	go sub.forward()
+	return sub, nil
+}
+
+// forward 转换消息 pubsub关闭后关闭消息通道
+func (s *Subscription) forward() {
+	defer close(s.messages)
+	for msg := range s.pubsub.Channel() {
+		message := &Message{
+			Outcome: &Outcome{Primordial: msg.Payload, codec: s.codec, decompress: s.decompress},
+			Channel: strings.TrimPrefix(msg.Channel, s.prefix),
+			Pattern: strings.TrimPrefix(msg.Pattern, escapePattern(s.prefix)),
+		}
+		select {
+		case s.messages <- message:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Channel 消息通道 Close 后会被关闭
+func (s *Subscription) Channel() <-chan *Message {
+	return s.messages
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return s.pubsub.Close()
+}
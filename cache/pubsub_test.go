@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// receive 等待一条消息 超时则失败
+func receive(t *testing.T, sub *Subscription) *Message {
+	t.Helper()
+	select {
+	case msg, ok := <-sub.Channel():
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+func TestPublishDecodesOnSubscription(t *testing.T) {
+	rc, mr := newTestClient(t)
+	sub, err := rc.Subscribe("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if n, err := rc.Publish("events", testUser{Name: "bob", Age: 7}).GetInt64(); err != nil || n != 1 {
+		t.Fatalf("Publish = %d, %v", n, err)
+	}
+	msg := receive(t, sub)
+	if msg.Channel != "events" {
+		t.Fatalf("channel %q should have the prefix trimmed", msg.Channel)
+	}
+	var u testUser
+	if err := msg.Unmarshal(&u); err != nil || u != (testUser{Name: "bob", Age: 7}) {
+		t.Fatalf("decoded %+v, %v", u, err)
+	}
+	if n := mr.PubSubNumSub("app:test:events")["app:test:events"]; n != 1 {
+		t.Fatalf("raw channel should be namespaced, subscribers = %d", n)
+	}
+}
+
+func TestSubscriptionCloseClosesChannel(t *testing.T) {
+	rc, _ := newTestClient(t)
+	sub, err := rc.Subscribe("events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-sub.Channel():
+		if ok {
+			t.Fatal("unexpected message after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after Close")
+	}
+}
+
+func TestPSubscribeMatchesSeveralChannels(t *testing.T) {
+	rc, _ := newTestClient(t)
+	sub, err := rc.PSubscribe("orders.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// 其他命名空间和不匹配的频道都不应该收到
+	rc.WithNamespace("other").Publish("orders.created", "foreign")
+	rc.Publish("users.created", "skip")
+	for _, channel := range []string{"orders.created", "orders.paid"} {
+		if n, err := rc.Publish(channel, channel+"-body").GetInt64(); err != nil || n != 1 {
+			t.Fatalf("Publish %s = %d, %v", channel, n, err)
+		}
+	}
+	for _, want := range []string{"orders.created", "orders.paid"} {
+		msg := receive(t, sub)
+		if msg.Channel != want || msg.Pattern != "orders.*" {
+			t.Fatalf("got channel %q pattern %q, want %q from orders.*", msg.Channel, msg.Pattern, want)
+		}
+		if body, _ := msg.GetString(); body != want+"-body" {
+			t.Fatalf("body %q", body)
+		}
+	}
+	select {
+	case msg := <-sub.Channel():
+		t.Fatalf("unexpected message on %q", msg.Channel)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
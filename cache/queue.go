@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Queue 基于list的可靠队列 弹出的元素先移到处理中列表 处理完成后 Ack 才真正删除
+// 消费者崩溃时未Ack的元素留在处理中列表 可以通过 Requeue 放回队列
+type Queue struct {
+	rc         *RedisClient
+	name       string
+	processing string
+}
+
+// Queue 获取一个队列 key使用hash tag保证集群模式下队列和处理中列表在同一个slot
+func (rc *RedisClient) Queue(name string) *Queue {
+	return &Queue{
+		rc:         rc,
+		name:       "{" + name + "}",
+		processing: "{" + name + "}:processing",
+	}
+}
+
+// Push 入队 值会自动序列化 返回队列长度int64
+func (q *Queue) Push(v interface{}) *Outcome {
+	return q.rc.LPush(q.name, v)
+}
+
+// Pop 阻塞地出队并移到处理中列表 返回string 可以直接 Unmarshal
+// 超时返回的 IsNil 为true 处理完成后需要调用 Ack
+func (q *Queue) Pop(ctx context.Context, timeout time.Duration) *Outcome {
+	cmd := q.rc.Runner().BRPopLPush(q.rc.context(ctx), q.rc.GetKey(q.name), q.rc.GetKey(q.processing), timeout)
+	return q.rc.keyOutcome(q.name, cmd.Val(), cmd)
+}
+
+// Ack 确认处理完成 从处理中列表删除 item为 Pop 的返回值 返回删除的数量int64
+func (q *Queue) Ack(item *Outcome) *Outcome {
+	value, err := item.GetString()
+	if err != nil {
+		return q.rc.Outcome(nil, err)
+	}
+	return q.rc.LRem(q.processing, 1, value)
+}
+
+// Pending 获取处理中列表内未确认的元素 返回[]string
+func (q *Queue) Pending() *Outcome {
+	return q.rc.LRange(q.processing, 0, -1)
+}
+
+// Requeue 将处理中列表内未确认的元素放回队列 返回放回的数量
+// 只应在确认没有消费者正在处理时调用 例如消费者重启后
+func (q *Queue) Requeue() (int64, error) {
+	var moved int64
+	for {
+		cmd := q.rc.Runner().RPopLPush(q.rc.ctx, q.rc.GetKey(q.processing), q.rc.GetKey(q.name))
+		if err := cmd.Err(); err != nil {
+			if err == Nil {
+				return moved, nil
+			}
+			return moved, q.rc.WrapError(cmd.Name(), q.processing, err)
+		}
+		moved++
+	}
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueAckRemovesFromProcessing(t *testing.T) {
+	rc, _ := newTestClient(t)
+	q := rc.Queue("jobs")
+	q.Push(testUser{Name: "a"})
+	q.Push(testUser{Name: "b"})
+
+	item := q.Pop(context.Background(), time.Second)
+	var u testUser
+	if err := item.Unmarshal(&u); err != nil || u.Name != "a" {
+		t.Fatalf("Pop = %+v, %v", u, err)
+	}
+	if pending, _ := q.Pending().GetStringSlice(); len(pending) != 1 {
+		t.Fatalf("pending = %v", pending)
+	}
+	if n, err := q.Ack(item).GetInt64(); err != nil || n != 1 {
+		t.Fatalf("Ack = %d, %v", n, err)
+	}
+	if pending, _ := q.Pending().GetStringSlice(); len(pending) != 0 {
+		t.Fatalf("pending after Ack = %v", pending)
+	}
+}
+
+func TestQueueRecoversUnackedItems(t *testing.T) {
+	rc, mr := newTestClient(t)
+	q := rc.Queue("jobs")
+	q.Push("job-1")
+	q.Push("job-2")
+
+	// 消费者取出后崩溃 没有Ack
+	if got, _ := q.Pop(context.Background(), time.Second).GetString(); got != "job-1" {
+		t.Fatalf("Pop = %q", got)
+	}
+	if !mr.Exists("app:test:{jobs}:processing") {
+		t.Fatal("processing list should share the queue's hash tag")
+	}
+	if pending, _ := q.Pending().GetStringSlice(); len(pending) != 1 || pending[0] != "job-1" {
+		t.Fatalf("pending = %v", pending)
+	}
+
+	if n, err := q.Requeue(); err != nil || n != 1 {
+		t.Fatalf("Requeue = %d, %v", n, err)
+	}
+	// 放回的元素与新入队的一样排在队列末尾
+	for _, want := range []string{"job-2", "job-1"} {
+		if got, _ := q.Pop(context.Background(), time.Second).GetString(); got != want {
+			t.Fatalf("Pop = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestQueuePopTimeout(t *testing.T) {
+	rc, _ := newTestClient(t)
+	if !rc.Queue("empty").Pop(context.Background(), time.Second).IsNil() {
+		t.Fatal("Pop on an empty queue should miss after the timeout")
+	}
+}
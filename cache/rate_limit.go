@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// fixedWindowScript 计数加1 窗口内第一次请求时设置过期时间
+const fixedWindowScript = `
+local current = redis.call("INCR", KEYS[1])
+if current == 1 or redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return current
+`
+
+// slidingWindowScript 移除窗口外的请求后统计 未超过limit时记录本次请求
+const slidingWindowScript = `
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], math.ceil(window / 1000))
+return 1
+`
+
+// Allow 固定窗口限流 window内最多允许limit次请求 返回是否允许以及窗口内剩余次数
+func (rc *RedisClient) Allow(key string, limit int64, window time.Duration) (allowed bool, remaining int64, err error) {
+	outcome := rc.Eval(fixedWindowScript, []string{key}, window.Milliseconds())
+	if outcome.Error != nil {
+		return false, 0, outcome.Error
+	}
+	current, err := outcome.GetInt64()
+	if err != nil {
+		return false, 0, err
+	}
+	remaining = limit - current
+	if remaining < 0 {
+		remaining = 0
+	}
+	return current <= limit, remaining, nil
+}
+
+// AllowSliding 滑动窗口限流 任意长度为window的时间段内最多允许limit次请求
+func (rc *RedisClient) AllowSliding(key string, limit int64, window time.Duration) (bool, error) {
+	now := time.Now().UnixMicro()
+	member := fmt.Sprintf("%d-%d", now, rand.Int63())
+	outcome := rc.Eval(slidingWindowScript, []string{key}, now, window.Microseconds(), limit, member)
+	if outcome.Error != nil {
+		return false, outcome.Error
+	}
+	allowed, err := outcome.GetInt64()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
+
+// tokenBucketScript 按经过的时间补充令牌 令牌足够时扣减
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local requested = tonumber(ARGV[4])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= requested then
+	tokens = tokens - requested
+	allowed = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tokens, "ts", now)
+if rate > 0 then
+	redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate * 1000) + 1000)
+end
+return allowed
+`
+
+// TokenBucket 令牌桶限流 令牌数和上次补充时间保存在hash中
+type TokenBucket struct {
+	rc           *RedisClient
+	key          string
+	capacity     int64
+	refillPerSec float64
+}
+
+// TokenBucket 获取一个令牌桶 容量为capacity 每秒补充refillPerSec个令牌
+func (rc *RedisClient) TokenBucket(key string, capacity int64, refillPerSec float64) *TokenBucket {
+	return &TokenBucket{
+		rc:           rc,
+		key:          key,
+		capacity:     capacity,
+		refillPerSec: refillPerSec,
+	}
+}
+
+// Take 获取n个令牌 令牌不足时返回false且不扣减
+func (tb *TokenBucket) Take(n int64) (bool, error) {
+	now := time.Now().UnixMilli()
+	outcome := tb.rc.Eval(tokenBucketScript, []string{tb.key}, tb.capacity, tb.refillPerSec, now, n)
+	if outcome.Error != nil {
+		return false, outcome.Error
+	}
+	allowed, err := outcome.GetInt64()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowFixedWindow(t *testing.T) {
+	rc, mr := newTestClient(t)
+	for i := int64(1); i <= 3; i++ {
+		allowed, remaining, err := rc.Allow("api", 3, time.Minute)
+		if err != nil || !allowed || remaining != 3-i {
+			t.Fatalf("request %d = %v, %d, %v", i, allowed, remaining, err)
+		}
+	}
+	if allowed, remaining, err := rc.Allow("api", 3, time.Minute); err != nil || allowed || remaining != 0 {
+		t.Fatalf("request over limit = %v, %d, %v", allowed, remaining, err)
+	}
+	if ttl := mr.TTL("app:test:api"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("window ttl = %s", ttl)
+	}
+
+	mr.FastForward(time.Minute)
+	if allowed, remaining, err := rc.Allow("api", 3, time.Minute); err != nil || !allowed || remaining != 2 {
+		t.Fatalf("request in next window = %v, %d, %v", allowed, remaining, err)
+	}
+}
+
+func TestAllowSlidingThrottlesAcrossRollingWindow(t *testing.T) {
+	rc, _ := newTestClient(t)
+	window := 600 * time.Millisecond
+	allow := func(want bool, step string) {
+		t.Helper()
+		allowed, err := rc.AllowSliding("api", 2, window)
+		if err != nil || allowed != want {
+			t.Fatalf("%s: allowed = %v, %v; want %v", step, allowed, err, want)
+		}
+	}
+
+	allow(true, "first")
+	time.Sleep(300 * time.Millisecond)
+	allow(true, "second")
+	allow(false, "third within window")
+
+	// 第一次请求已滑出窗口 第二次仍在窗口内 只释放出一个名额
+	time.Sleep(350 * time.Millisecond)
+	allow(true, "after first expired")
+	allow(false, "second still counted")
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	rc, mr := newTestClient(t)
+	bucket := rc.TokenBucket("upload", 3, 10)
+	for i := 0; i < 3; i++ {
+		if ok, err := bucket.Take(1); err != nil || !ok {
+			t.Fatalf("take %d = %v, %v", i, ok, err)
+		}
+	}
+	if ok, err := bucket.Take(1); err != nil || ok {
+		t.Fatalf("drained bucket = %v, %v", ok, err)
+	}
+	if ok, _ := bucket.Take(4); ok {
+		t.Fatal("taking more than capacity should fail")
+	}
+	if !mr.Exists("app:test:upload") {
+		t.Fatal("bucket key should be namespaced")
+	}
+
+	// 每秒补充10个 200ms后约有2个令牌
+	time.Sleep(200 * time.Millisecond)
+	if ok, err := bucket.Take(1); err != nil || !ok {
+		t.Fatalf("take after refill = %v, %v", ok, err)
+	}
+	if ok, _ := bucket.Take(3); ok {
+		t.Fatal("bucket should not refill past what elapsed time allows")
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// RedLocker 基于Redlock算法的多节点锁
+// 各节点是相互独立的redis部署 过半数节点加锁成功且仍在有效期内才算加锁成功
+type RedLocker struct {
+	TimeOut time.Duration
+	clients []*RedisClient
+}
+
+// NewRedLocker 实例化一个多节点锁 每个Options对应一个独立的redis节点
+func NewRedLocker(timeout time.Duration, opts ...*Options) (*RedLocker, error) {
+	clients := make([]*RedisClient, 0, len(opts))
+	for i := range opts {
+		client, err := NewRedisClient(opts[i])
+		if err != nil {
+			for j := range clients {
+				_ = clients[j].Close()
+			}
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return &RedLocker{
+		TimeOut: timeout,
+		clients: clients,
+	}, nil
+}
+
+// Lock 在所有节点上加锁 过半数成功且耗时未超过有效期时返回true 否则释放已加的锁
+func (rl *RedLocker) Lock(name string, topic string) bool {
+	start := time.Now()
+	acquired := 0
+	for _, rc := range rl.clients {
+		ctx, cancel := rl.nodeContext(rc)
+		cmd := rc.Runner().SetNX(ctx, rc.GetKey(name), topic, rl.TimeOut)
+		cancel()
+		if cmd.Err() == nil && cmd.Val() {
+			acquired++
+		}
+	}
+	// 时钟漂移 按Redlock建议取TTL的1%再加2ms
+	drift := rl.TimeOut/100 + 2*time.Millisecond
+	validity := rl.TimeOut - time.Since(start) - drift
+	if acquired >= rl.quorum() && validity > 0 {
+		return true
+	}
+	rl.Unlock(name, topic)
+	return false
+}
+
+// Unlock 在所有节点上释放锁 过半数节点释放成功时返回true
+func (rl *RedLocker) Unlock(name string, topic string) bool {
+	released := 0
+	for _, rc := range rl.clients {
+		ctx, cancel := rl.nodeContext(rc)
+		cmd := unlockScript.Run(ctx, rc.Runner(), []string{rc.GetKey(name)}, topic)
+		cancel()
+		if n, err := cmd.Int64(); err == nil && n == 1 {
+			released++
+		}
+	}
+	return released >= rl.quorum()
+}
+
+// Close 关闭所有节点的连接池
+func (rl *RedLocker) Close() error {
+	var err error
+	for _, rc := range rl.clients {
+		if e := rc.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// quorum 过半数
+func (rl *RedLocker) quorum() int {
+	return len(rl.clients)/2 + 1
+}
+
+// nodeContext 单个节点的操作超时要远小于锁的有效期 避免节点宕机时阻塞太久
+func (rl *RedLocker) nodeContext(rc *RedisClient) (context.Context, context.CancelFunc) {
+	timeout := rl.TimeOut / 10
+	if timeout < 50*time.Millisecond {
+		timeout = 50 * time.Millisecond
+	}
+	return context.WithTimeout(rc.ctx, timeout)
+}
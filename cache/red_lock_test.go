@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newRedLockNodes 启动n个相互独立的节点
+func newRedLockNodes(t *testing.T, n int) ([]*miniredis.Miniredis, []*Options) {
+	t.Helper()
+	nodes := make([]*miniredis.Miniredis, 0, n)
+	opts := make([]*Options, 0, n)
+	for i := 0; i < n; i++ {
+		mr := miniredis.RunT(t)
+		nodes = append(nodes, mr)
+		opts = append(opts, &Options{AppName: "app", NameSpace: "test", Addr: []string{mr.Addr()}, MaxRetries: -1})
+	}
+	return nodes, opts
+}
+
+func TestRedLockQuorumWithOneNodeDown(t *testing.T) {
+	nodes, opts := newRedLockNodes(t, 3)
+	rl, err := NewRedLocker(time.Second, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+	nodes[2].Close()
+
+	if !rl.Lock("job", "a") {
+		t.Fatal("two of three nodes should reach quorum")
+	}
+	for _, mr := range nodes[:2] {
+		if got, _ := mr.Get("app:test:job"); got != "a" {
+			t.Fatalf("node lock value %q", got)
+		}
+	}
+	if rl.Lock("job", "b") {
+		t.Fatal("second owner must not acquire the lock")
+	}
+	if !rl.Unlock("job", "a") {
+		t.Fatal("owner should release on a quorum")
+	}
+	for _, mr := range nodes[:2] {
+		if mr.Exists("app:test:job") {
+			t.Fatal("lock should be released on every live node")
+		}
+	}
+}
+
+func TestRedLockFailsWithoutQuorum(t *testing.T) {
+	nodes, opts := newRedLockNodes(t, 3)
+	rl, err := NewRedLocker(time.Second, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+	nodes[1].Close()
+	nodes[2].Close()
+
+	if rl.Lock("job", "a") {
+		t.Fatal("one of three nodes must not reach quorum")
+	}
+	if nodes[0].Exists("app:test:job") {
+		t.Fatal("partial locks should be released after a failed attempt")
+	}
+}
@@ -0,0 +1,23 @@
+package cache
+
+// SetBit 设置offset位的值 返回该位原来的值int64
+func (rc *RedisClient) SetBit(key string, offset int64, value int) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SetBit(rc.ctx, hook, offset, value)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GetBit 获取offset位的值 返回int64
+func (rc *RedisClient) GetBit(key string, offset int64) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GetBit(rc.ctx, hook, offset)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// BitCount 统计值为1的位数 返回int64
+func (rc *RedisClient) BitCount(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().BitCount(rc.ctx, hook, nil)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
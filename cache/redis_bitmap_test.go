@@ -0,0 +1,27 @@
+package cache
+
+import "testing"
+
+func TestBitmapPopulationCount(t *testing.T) {
+	rc, mr := newTestClient(t)
+	for _, offset := range []int64{1, 7, 100} {
+		if err := rc.SetBit("flags", offset, 1).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if old, err := rc.SetBit("flags", 7, 1).GetInt64(); err != nil || old != 1 {
+		t.Fatalf("SetBit should return the previous bit, got %d, %v", old, err)
+	}
+	if n, err := rc.BitCount("flags").GetInt64(); err != nil || n != 3 {
+		t.Fatalf("BitCount = %d, %v", n, err)
+	}
+	if bit, _ := rc.GetBit("flags", 100).GetInt64(); bit != 1 {
+		t.Fatal("bit 100 should be set")
+	}
+	if bit, _ := rc.GetBit("flags", 2).GetInt64(); bit != 0 {
+		t.Fatal("bit 2 should be clear")
+	}
+	if !mr.Exists("app:test:flags") {
+		t.Fatal("bitmap key should be namespaced")
+	}
+}
@@ -2,10 +2,13 @@ package cache
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 	"math/rand"
 	"reflect"
 	"strconv"
@@ -18,10 +21,34 @@ const Null = ""
 const Nil = redis.Nil
 const TypeMatchError = "type match error"
 
+var (
+	// ErrTypeMatch Outcome 的值类型与获取的类型不匹配 可以使用 errors.Is 判断
+	ErrTypeMatch = errors.New(TypeMatchError)
+	// ErrCacheMiss key不存在 Outcome 中的 redis.Nil 统一转换为该错误
+	ErrCacheMiss = errors.New("cache miss")
+	// ErrCrossSlot 集群模式下多key命令的key不在同一个slot
+	ErrCrossSlot = errors.New("keys must hash to the same slot in cluster mode")
+	// ErrNoExpire key存在但没有设置过期时间
+	ErrNoExpire = errors.New("key has no expire")
+	// ErrKeyMissing key不存在
+	ErrKeyMissing = errors.New("key does not exist")
+	// ErrAlreadyInitialized 全局客户端已经使用不同的配置初始化过
+	ErrAlreadyInitialized = errors.New("redis client already initialized with different options")
+)
+
+// DefaultKeySeparator 默认的key分隔符 与redis的惯例一致
+const DefaultKeySeparator = ":"
+
+// DefaultDriftWindow 默认的过期时间摆动范围
+const DefaultDriftWindow = 60 * time.Second
+
 type Options struct {
 	AppName string
 	NameSpace string
+	KeySeparator string
 	Addr []string
+	MasterName string
+	SentinelAddrs []string
 	Password string
 	DB int
 	MaxRetries int
@@ -36,13 +63,41 @@ type Options struct {
 	PoolTimeout time.Duration
 	IdleTimeout time.Duration
 	IdleCheckFrequency time.Duration
-	readOnly bool
+	DriftWindow time.Duration
+	DefaultTTL time.Duration
+	TxRetries int
+	Codec Codec
+	Compression Compression
+	CompressThreshold int
+	BreakerThreshold int
+	BreakerResetTimeout time.Duration
+	LocalCacheSize int
+	LocalCacheTTL time.Duration
+	TLSConfig *tls.Config
+	UseTLS bool
+	MetricsHook MetricsHook
+	Tracer trace.Tracer
+	HashTraceKey bool
+	Logger Logger
+	SlowThreshold time.Duration
+	OnSlow func(cmd, key string, d time.Duration)
+	// ReadOnly 集群模式下允许读命令发往从节点 默认按延迟选择节点 RouteRandomly 为true时随机选择
+	ReadOnly bool
+	RouteRandomly bool
 }
 
 // Outcome 统一结果返回值
 type Outcome struct {
 	Error error
 	Primordial interface{}
+	codec Codec
+	// decompress 客户端开启压缩时才尝试解压
+	decompress bool
+}
+
+// IsNil key不存在时返回true
+func (oc *Outcome) IsNil() bool {
+	return errors.Is(oc.Error, ErrCacheMiss) || oc.Error == Nil
 }
 
 func (oc *Outcome) GetInt64() (int64,error) {
@@ -55,14 +110,65 @@ func (oc *Outcome) GetInt64() (int64,error) {
 		}
 		return value, nil
 	}
-	return 0, errors.New(TypeMatchError)
+	return 0, ErrTypeMatch
+}
+
+// GetInt 获取int 超出int范围时返回错误
+func (oc *Outcome) GetInt() (int,error) {
+	if it,ok := oc.Primordial.(int);ok {
+		return it, nil
+	} else if it,ok := oc.Primordial.(int64);ok {
+		if int64(int(it)) != it {
+			return 0, fmt.Errorf("value %d overflows int", it)
+		}
+		return int(it), nil
+	} else if str,ok := oc.Primordial.(string);ok {
+		value, err := strconv.ParseInt(str, 10, strconv.IntSize)
+		if err != nil {
+			return 0, err
+		}
+		return int(value), nil
+	}
+	return 0, ErrTypeMatch
+}
+
+// GetUint64 获取uint64 负数返回错误
+func (oc *Outcome) GetUint64() (uint64,error) {
+	if it,ok := oc.Primordial.(uint64);ok {
+		return it, nil
+	} else if it,ok := oc.Primordial.(int64);ok {
+		if it < 0 {
+			return 0, fmt.Errorf("negative value %d cannot be converted to uint64", it)
+		}
+		return uint64(it), nil
+	} else if str,ok := oc.Primordial.(string);ok {
+		if strings.HasPrefix(strings.TrimSpace(str), "-") {
+			return 0, fmt.Errorf("negative value %q cannot be converted to uint64", str)
+		}
+		value, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return value, nil
+	}
+	return 0, ErrTypeMatch
 }
 
 func (oc *Outcome) GetString() (string,error) {
 	if str,ok := oc.Primordial.(string);ok {
 		return str, nil
 	}
-	return Null, errors.New(TypeMatchError)
+	return Null, ErrTypeMatch
+}
+
+// GetBytes 获取原始字节 不做json解析
+func (oc *Outcome) GetBytes() ([]byte,error) {
+	if bs,ok := oc.Primordial.([]byte);ok {
+		return bs, nil
+	} else if str,ok := oc.Primordial.(string);ok {
+		return []byte(str), nil
+	}
+	return nil, ErrTypeMatch
 }
 
 func (oc *Outcome) GetFloat64() (float64,error) {
@@ -75,7 +181,7 @@ func (oc *Outcome) GetFloat64() (float64,error) {
 		}
 		return value, nil
 	}
-	return 0, errors.New(TypeMatchError)
+	return 0, ErrTypeMatch
 }
 
 func (oc *Outcome) GetBool() (bool,error) {
@@ -88,18 +194,18 @@ func (oc *Outcome) GetBool() (bool,error) {
 		}
 		return value, nil
 	}
-	return false, errors.New(TypeMatchError)
+	return false, ErrTypeMatch
 }
 
 func (oc *Outcome) Unmarshal(v interface{}) error {
 	if str,ok := oc.Primordial.(string);ok {
-		err := json.Unmarshal([]byte(str), v)
+		err := oc.unmarshal(str, v)
 		if err != nil {
 			return err
 		}
 		return nil
 	}
-	return errors.New(TypeMatchError)
+	return ErrTypeMatch
 }
 
 func (oc *Outcome) GetMap() (map[string]string,error) {
@@ -107,13 +213,94 @@ func (oc *Outcome) GetMap() (map[string]string,error) {
 		return mp,nil
 	} else if str,ok := oc.Primordial.(string);ok {
 		var mp map[string]string
-		err := json.Unmarshal([]byte(str), &mp)
+		err := oc.unmarshal(str, &mp)
 		if err != nil {
 			return nil, err
 		}
 		return mp, nil
 	}
-	return nil,errors.New(TypeMatchError)
+	return nil,ErrTypeMatch
+}
+
+// ScanStruct 将HGetAll的结果映射到结构体 dest必须是结构体指针
+// field名依次取 redis 标签、json 标签、字段名 hash中不存在的字段保持原值
+func (oc *Outcome) ScanStruct(dest interface{}) error {
+	if oc.Error != nil {
+		return oc.Error
+	}
+	mp, err := oc.GetMap()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("dest must be a non-nil pointer to struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != Null {
+			continue
+		}
+		name := structFieldName(field)
+		if name == "-" {
+			continue
+		}
+		str, ok := mp[name]
+		if !ok {
+			continue
+		}
+		if err := setStructField(oc, rv.Field(i), str); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// structFieldName 获取结构体字段对应的hash field
+func structFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"redis", "json"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != Null {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// setStructField 将字符串转换为字段的类型 复杂类型使用codec解析
+func setStructField(oc *Outcome, fv reflect.Value, str string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(str)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(str, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(str, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(str, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(value)
+	default:
+		return oc.unmarshal(str, fv.Addr().Interface())
+	}
+	return nil
 }
 
 func (oc *Outcome) GetArray() ([]string,error) {
@@ -121,13 +308,109 @@ func (oc *Outcome) GetArray() ([]string,error) {
 		return arr,nil
 	} else if str,ok := oc.Primordial.(string);ok {
 		var arr []string
-		err := json.Unmarshal([]byte(str), &arr)
+		err := oc.unmarshal(str, &arr)
 		if err != nil {
 			return nil, err
 		}
 		return arr, nil
 	}
-	return nil,errors.New(TypeMatchError)
+	return nil,ErrTypeMatch
+}
+
+// GetStructArray 将SMembers、LRange等结果的每个元素解析到dest的元素中 dest必须是切片指针
+func (oc *Outcome) GetStructArray(dest interface{}) error {
+	arr, err := oc.GetStringSlice()
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return ErrTypeMatch
+	}
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(arr), len(arr))
+	for i := range arr {
+		if err := oc.unmarshal(arr[i], slice.Index(i).Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
+
+// GetStringSlice 获取MGet的结果 不存在的key对应空字符串
+func (oc *Outcome) GetStringSlice() ([]string,error) {
+	if arr,ok := oc.Primordial.([]string);ok {
+		return arr,nil
+	} else if raw,ok := oc.Primordial.([]interface{});ok {
+		arr := make([]string, 0, len(raw))
+		for i := range raw {
+			if raw[i] == nil {
+				arr = append(arr, Null)
+			} else if str,ok := raw[i].(string);ok {
+				arr = append(arr, str)
+			} else {
+				return nil,ErrTypeMatch
+			}
+		}
+		return arr,nil
+	}
+	return nil,ErrTypeMatch
+}
+
+// GetDuration 获取时长 TTL的-1和-2分别返回 ErrNoExpire 和 ErrKeyMissing
+func (oc *Outcome) GetDuration() (time.Duration,error) {
+	if dur,ok := oc.Primordial.(time.Duration);ok {
+		switch dur {
+		case -1:
+			return 0, ErrNoExpire
+		case -2:
+			return 0, ErrKeyMissing
+		}
+		return dur, nil
+	} else if str,ok := oc.Primordial.(string);ok {
+		value, err := time.ParseDuration(str)
+		if err != nil {
+			return 0, err
+		}
+		return value, nil
+	}
+	return 0, ErrTypeMatch
+}
+
+// GetZSlice 获取有序集合的成员和分数
+func (oc *Outcome) GetZSlice() ([]redis.Z,error) {
+	if zs,ok := oc.Primordial.([]redis.Z);ok {
+		return zs,nil
+	}
+	return nil,ErrTypeMatch
+}
+
+// GetGeoLocations 获取GeoRadius的结果
+func (oc *Outcome) GetGeoLocations() ([]redis.GeoLocation,error) {
+	if locations,ok := oc.Primordial.([]redis.GeoLocation);ok {
+		return locations,nil
+	}
+	return nil,ErrTypeMatch
+}
+
+// GetXStreams 获取XRead/XReadGroup的结果
+func (oc *Outcome) GetXStreams() ([]redis.XStream,error) {
+	if streams,ok := oc.Primordial.([]redis.XStream);ok {
+		return streams,nil
+	}
+	return nil,ErrTypeMatch
+}
+
+// Decode 泛型解析 oc.Error 不为空时直接返回该错误
+func Decode[T any](oc *Outcome) (T, error) {
+	var v T
+	if oc.Error != nil {
+		return v, oc.Error
+	}
+	if err := oc.Unmarshal(&v); err != nil {
+		return v, err
+	}
+	return v, nil
 }
 
 type Cache interface {
@@ -163,90 +446,221 @@ type Cache interface {
 	HIncrBy(key string,field string,incr int64) *Outcome
 	HIncrByFloat(key, field string, incr float64) *Outcome
 
+	LPush(key string, values ...interface{}) *Outcome
+	RPush(key string, values ...interface{}) *Outcome
+	LPop(key string) *Outcome
+	RPop(key string) *Outcome
+	LLen(key string) *Outcome
+	LRange(key string, start, stop int64) *Outcome
+
+	SAdd(key string, members ...interface{}) *Outcome
+	SRem(key string, members ...interface{}) *Outcome
+	SMembers(key string) *Outcome
+	SIsMember(key string, member interface{}) *Outcome
+	SCard(key string) *Outcome
+
+	ZAdd(key string, members ...redis.Z) *Outcome
+	ZRem(key string, members ...interface{}) *Outcome
+	ZRange(key string, start, stop int64) *Outcome
+	ZRangeWithScores(key string, start, stop int64) *Outcome
+	ZRangeByScore(key string, opt *redis.ZRangeBy) *Outcome
+	ZScore(key string, member interface{}) *Outcome
+	ZRank(key string, member interface{}) *Outcome
+
 }
 
 var (
 	redisClient *RedisClient
-	once sync.Once
+	// redisClientMu 保护全局客户端的初始化、读取和 Close 时的重置
+	redisClientMu sync.Mutex
 )
 
+var _ Cache = (*RedisClient)(nil)
+
 // RedisClient Redis客户端
 type RedisClient struct {
-	Cache
 	opt *Options
 	ctx context.Context
 	single *redis.Client
 	cluster *redis.ClusterClient
 	flag bool
+	group *singleflight.Group
+	local *localCache
+	invalidation *redis.PubSub
+	timeout time.Duration
 }
 
-// InitRedisClient 初始化
+// InitRedisClient 初始化全局客户端 重复调用时配置相同则直接返回 配置不同时返回 ErrAlreadyInitialized
+// 配置中设置了函数(如OnSlow)时只有同一个Options指针视为相同 初始化失败后可以重新调用 需要更换配置时先 Close
 func InitRedisClient(opt *Options) error {
-	var err error
-	once.Do(func() {
-		if opt == nil {
-			err = errors.New("options is null")
-			return
-		} else {
-			client := new(RedisClient)
-			client.opt = opt
-			client.ctx = context.Background()
-			if len(opt.Addr) <= 0 {
-				err = errors.New("addr is null")
-				return
-			} else if len(opt.Addr) == 1 {
-				client.single = redis.NewClient(&redis.Options{
-					Network:            "tcp",
-					Addr:               opt.Addr[0],
-					Password:           opt.Password,
-					DB:                 opt.DB,
-					MaxRetries:         opt.MaxRetries,
-					MinRetryBackoff:    opt.MinRetryBackoff,
-					MaxRetryBackoff:    opt.MaxRetryBackoff,
-					DialTimeout:        opt.DialTimeout,
-					ReadTimeout:        opt.ReadTimeout,
-					WriteTimeout:       opt.WriteTimeout,
-					PoolSize:           opt.PoolSize,
-					MinIdleConns:       opt.MinIdleConn,
-					MaxConnAge:         opt.MaxConnAge,
-					PoolTimeout:        opt.PoolTimeout,
-					IdleTimeout:        opt.IdleTimeout,
-					IdleCheckFrequency: opt.IdleCheckFrequency,
-				})
-				client.flag = true
-			} else {
-				client.cluster = redis.NewClusterClient(&redis.ClusterOptions{
-					Addrs:              opt.Addr,
-					MaxRedirects:       opt.MaxRetries,
-					ReadOnly:           opt.readOnly,
-					Password:           opt.Password,
-					MaxRetries:         opt.MaxRetries,
-					MinRetryBackoff:    opt.MinRetryBackoff,
-					MaxRetryBackoff:    opt.MaxRetryBackoff,
-					DialTimeout:        opt.DialTimeout,
-					ReadTimeout:        opt.ReadTimeout,
-					WriteTimeout:       opt.WriteTimeout,
-					PoolSize:           opt.PoolSize,
-					MinIdleConns:       opt.MinIdleConn,
-					MaxConnAge:         opt.MaxConnAge,
-					PoolTimeout:        opt.PoolTimeout,
-					IdleTimeout:        opt.IdleTimeout,
-					IdleCheckFrequency: opt.IdleCheckFrequency,
-				})
-				client.flag = false
-			}
-			redisClient = client
-			return
+	redisClientMu.Lock()
+	defer redisClientMu.Unlock()
+	if redisClient != nil {
+		if opt != redisClient.opt && !reflect.DeepEqual(opt, redisClient.opt) {
+			return fmt.Errorf("%w: initialized with app %q namespace %q addr %v", ErrAlreadyInitialized,
+				redisClient.opt.AppName, redisClient.opt.NameSpace, redisClient.opt.Addr)
 		}
+		return nil
+	}
+	client, err := NewRedisClient(opt)
+	if err != nil {
+		return err
+	}
+	redisClient = client
+	return nil
+}
 
-	})
-	return err
+// NewRedisClient 根据配置创建一个独立的客户端 可以同时连接多个redis 不影响 InitRedisClient 初始化的全局客户端
+func NewRedisClient(opt *Options) (*RedisClient, error) {
+	if opt == nil {
+		return nil, errors.New("options is null")
+	}
+	client := new(RedisClient)
+	client.opt = opt
+	client.ctx = context.Background()
+	client.group = new(singleflight.Group)
+	if opt.LocalCacheSize > 0 {
+		client.local = newLocalCache(opt.LocalCacheSize, opt.LocalCacheTTL)
+	}
+	tlsConfig := opt.TLSConfig
+	if tlsConfig == nil && opt.UseTLS {
+		tlsConfig = &tls.Config{}
+	}
+	if opt.MasterName != Null {
+		if len(opt.SentinelAddrs) <= 0 {
+			return nil, errors.New("sentinel addr is null")
+		}
+		client.single = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:         opt.MasterName,
+			SentinelAddrs:      opt.SentinelAddrs,
+			Password:           opt.Password,
+			DB:                 opt.DB,
+			MaxRetries:         opt.MaxRetries,
+			MinRetryBackoff:    opt.MinRetryBackoff,
+			MaxRetryBackoff:    opt.MaxRetryBackoff,
+			DialTimeout:        opt.DialTimeout,
+			ReadTimeout:        opt.ReadTimeout,
+			WriteTimeout:       opt.WriteTimeout,
+			PoolSize:           opt.PoolSize,
+			MinIdleConns:       opt.MinIdleConn,
+			MaxConnAge:         opt.MaxConnAge,
+			PoolTimeout:        opt.PoolTimeout,
+			IdleTimeout:        opt.IdleTimeout,
+			IdleCheckFrequency: opt.IdleCheckFrequency,
+			TLSConfig:          tlsConfig,
+		})
+		client.flag = true
+	} else if len(opt.Addr) <= 0 {
+		return nil, errors.New("addr is null")
+	} else if len(opt.Addr) == 1 {
+		client.single = redis.NewClient(&redis.Options{
+			Network:            "tcp",
+			Addr:               opt.Addr[0],
+			Password:           opt.Password,
+			DB:                 opt.DB,
+			MaxRetries:         opt.MaxRetries,
+			MinRetryBackoff:    opt.MinRetryBackoff,
+			MaxRetryBackoff:    opt.MaxRetryBackoff,
+			DialTimeout:        opt.DialTimeout,
+			ReadTimeout:        opt.ReadTimeout,
+			WriteTimeout:       opt.WriteTimeout,
+			PoolSize:           opt.PoolSize,
+			MinIdleConns:       opt.MinIdleConn,
+			MaxConnAge:         opt.MaxConnAge,
+			PoolTimeout:        opt.PoolTimeout,
+			IdleTimeout:        opt.IdleTimeout,
+			IdleCheckFrequency: opt.IdleCheckFrequency,
+			TLSConfig:          tlsConfig,
+		})
+		client.flag = true
+	} else {
+		client.cluster = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:              opt.Addr,
+			MaxRedirects:       opt.MaxRetries,
+			ReadOnly:           opt.ReadOnly,
+			RouteByLatency:     opt.ReadOnly && !opt.RouteRandomly,
+			RouteRandomly:      opt.ReadOnly && opt.RouteRandomly,
+			Password:           opt.Password,
+			MaxRetries:         opt.MaxRetries,
+			MinRetryBackoff:    opt.MinRetryBackoff,
+			MaxRetryBackoff:    opt.MaxRetryBackoff,
+			DialTimeout:        opt.DialTimeout,
+			ReadTimeout:        opt.ReadTimeout,
+			WriteTimeout:       opt.WriteTimeout,
+			PoolSize:           opt.PoolSize,
+			MinIdleConns:       opt.MinIdleConn,
+			MaxConnAge:         opt.MaxConnAge,
+			PoolTimeout:        opt.PoolTimeout,
+			IdleTimeout:        opt.IdleTimeout,
+			IdleCheckFrequency: opt.IdleCheckFrequency,
+			TLSConfig:          tlsConfig,
+		})
+		client.flag = false
+	}
+	metrics := opt.MetricsHook
+	if metrics == nil {
+		metrics = NoopMetricsHook{}
+	}
+	client.addHook(timeoutHook{})
+	client.addHook(metricsHook{metrics: metrics})
+	if opt.Tracer != nil {
+		client.addHook(tracingHook{tracer: opt.Tracer, hashKey: opt.HashTraceKey})
+	}
+	logger := opt.Logger
+	if logger == nil {
+		logger = NoopLogger{}
+	}
+	client.addHook(logHook{logger: logger, slow: opt.SlowThreshold, onSlow: opt.OnSlow})
+	// 熔断器放在最后 被熔断的命令仍会经过前面的指标和链路钩子
+	if opt.BreakerThreshold > 0 {
+		resetTimeout := opt.BreakerResetTimeout
+		if resetTimeout <= 0 {
+			resetTimeout = DefaultBreakerResetTimeout
+		}
+		client.addHook(&circuitBreaker{
+			threshold:    opt.BreakerThreshold,
+			resetTimeout: resetTimeout,
+		})
+	}
+	if client.local != nil {
+		client.listenInvalidation()
+	}
+	return client, nil
 }
 
 func GetRedis() *RedisClient {
+	redisClientMu.Lock()
+	defer redisClientMu.Unlock()
 	return redisClient
 }
 
+// Close 关闭连接池 关闭后可以重新调用 InitRedisClient 初始化
+func (rc *RedisClient) Close() error {
+	var err error
+	if rc.invalidation != nil {
+		_ = rc.invalidation.Close()
+	}
+	if rc.flag {
+		err = rc.single.Close()
+	} else {
+		err = rc.cluster.Close()
+	}
+	redisClientMu.Lock()
+	if rc == redisClient {
+		redisClient = nil
+	}
+	redisClientMu.Unlock()
+	return err
+}
+
+// PoolStats 获取连接池状态
+func (rc *RedisClient) PoolStats() *redis.PoolStats {
+	if rc.flag {
+		return rc.single.PoolStats()
+	}
+	return rc.cluster.PoolStats()
+}
+
 // Runner 获取一个redis可执行对象
 func (rc *RedisClient) Runner() redis.Cmdable {
 	var capable interface{}
@@ -258,10 +672,54 @@ func (rc *RedisClient) Runner() redis.Cmdable {
 	return capable.(redis.Cmdable)
 }
 
-// GetKey 获取统一Key
+// GetKey 获取统一Key 格式为 AppName{sep}NameSpace{sep}raw 分隔符未配置时使用 DefaultKeySeparator
 func (rc *RedisClient) GetKey(raw interface{}) string {
-	return fmt.Sprintf("%s-%s-%v",
-		rc.opt.AppName,rc.opt.NameSpace,raw)
+	sep := rc.opt.KeySeparator
+	if sep == Null {
+		sep = DefaultKeySeparator
+	}
+	return rc.opt.AppName + sep + rc.opt.NameSpace + sep + keyPart(raw)
+}
+
+// keyPart 将原始key转换为字符串 string和[]byte按字节原样保留 基本类型格式化
+// time.Time统一转为UTC的RFC3339Nano 避免时区和单调时钟影响key 其他类型使用json编码
+// 保证相同的值总是得到相同的key 指针按指向的值编码
+func keyPart(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Sprint(raw)
+	}
+	return string(data)
+}
+
+// WithNamespace 返回一个浅拷贝 共用连接池 key位于当前命名空间下的子命名空间ns
+// 拷贝共用连接池 不要对拷贝调用 Close
+func (rc *RedisClient) WithNamespace(ns string) *RedisClient {
+	opt := *rc.opt
+	if opt.NameSpace == Null {
+		opt.NameSpace = ns
+	} else {
+		sep := opt.KeySeparator
+		if sep == Null {
+			sep = DefaultKeySeparator
+		}
+		opt.NameSpace = opt.NameSpace + sep + ns
+	}
+	clone := *rc
+	clone.opt = &opt
+	return &clone
 }
 
 // GetKeys 获取多个统一key
@@ -274,17 +732,57 @@ func (rc *RedisClient) GetKeys(raw ...interface{}) []string {
 }
 
 
-// GetValue 自动序列化
+// crossSlot 将服务器返回的CROSSSLOT错误包装为 ErrCrossSlot
+func crossSlot(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), "CROSSSLOT") {
+		return fmt.Errorf("%w: %v", ErrCrossSlot, err)
+	}
+	return err
+}
+
+// getHooks 获取多个统一key
+func (rc *RedisClient) getHooks(keys []string) []string {
+	hooks := make([]string, 0, len(keys))
+	for i := range keys {
+		hooks = append(hooks, rc.GetKey(keys[i]))
+	}
+	return hooks
+}
+
+// context 未传入context时使用默认的 rc.ctx 传入的context同样带上 WithTimeout 设置的时长
+func (rc *RedisClient) context(ctx context.Context) context.Context {
+	if ctx == nil {
+		return rc.ctx
+	}
+	if rc.timeout > 0 {
+		return context.WithValue(ctx, timeoutKey{}, rc.timeout)
+	}
+	return ctx
+}
+
+// GetValue 自动序列化 使用 Options.Codec 默认为json 开启压缩时超过阈值的内容会被压缩
 func (rc *RedisClient) GetValue(raw interface{}) interface{} {
+	data, ok, err := rc.marshal(raw)
+	if err != nil {
+		return nil
+	}
+	if !ok {
+		return raw
+	}
+	return string(rc.compress(data))
+}
+
+// marshal 序列化复合类型 基础类型及nil原样返回 ok为false
+func (rc *RedisClient) marshal(raw interface{}) (data []byte, ok bool, err error) {
+	if raw == nil {
+		return nil, false, nil
+	}
 	switch reflect.TypeOf(raw).Kind() {
 	case reflect.Struct,reflect.Slice,reflect.Map,reflect.Array,reflect.Ptr:
-		marshal, err := json.Marshal(raw)
-		if err != nil {
-			return nil
-		}
-		return string(marshal)
+		data, err = rc.codec().Marshal(raw)
+		return data, true, err
 	default:
-		return raw
+		return nil, false, nil
 	}
 }
 
@@ -298,194 +796,681 @@ func (rc *RedisClient) GetValues(raw []interface{}) []interface{} {
 }
 
 // Drift 获取一个摆动值，防止缓存雪崩
+// 在过期时间上追加 [0, DriftWindow) 的随机值，DriftWindow 为0时使用默认值，小于0时不摆动
 func (rc *RedisClient) Drift(duration time.Duration) time.Duration {
-	drift := rand.Int63n(60)
-	return time.Duration(duration.Nanoseconds() + drift)
+	if duration <= 0 {
+		return duration
+	}
+	window := rc.opt.DriftWindow
+	if window == 0 {
+		window = DefaultDriftWindow
+	}
+	if window < 0 {
+		return duration
+	}
+	return duration + time.Duration(rand.Int63n(int64(window)))
 }
 
-// Outcome 生成统一返回值
+// Outcome 生成统一返回值 未命中的 redis.Nil 转换为 ErrCacheMiss
 func (rc *RedisClient) Outcome(value interface{},err error) *Outcome {
+	if err == Nil {
+		err = ErrCacheMiss
+	}
 	if err == nil {
 		return &Outcome{
 			Error:      nil,
 			Primordial: value,
+			codec:      rc.codec(),
+			decompress: rc.opt.Compression != CompressionNone,
 		}
 	} else {
 		return &Outcome{
 			Error:      err,
 			Primordial: nil,
+			codec:      rc.codec(),
+			decompress: rc.opt.Compression != CompressionNone,
 		}
 	}
 }
 
+// WrapError 错误中附带命令和key 便于排查 未命中不包装 仍可使用 errors.Is 判断原始错误
+func (rc *RedisClient) WrapError(command string, key string, err error) error {
+	if err == nil || err == Nil || err == ErrCacheMiss {
+		return err
+	}
+	return fmt.Errorf("redis %s on key %q: %w", command, key, err)
+}
+
+// keyOutcome 生成统一返回值 错误附带命令和key
+func (rc *RedisClient) keyOutcome(key string, value interface{}, cmd redis.Cmder) *Outcome {
+	return rc.Outcome(value, rc.WrapError(cmd.Name(), key, cmd.Err()))
+}
+
+// keysOutcome 多key命令的 keyOutcome 错误附带命令和所有key
+func (rc *RedisClient) keysOutcome(keys []string, value interface{}, cmd redis.Cmder) *Outcome {
+	return rc.Outcome(value, rc.wrapKeysError(cmd.Name(), keys, cmd.Err()))
+}
+
+// wrapKeysError 多key命令的 WrapError 只有一个key时与 WrapError 相同
+func (rc *RedisClient) wrapKeysError(command string, keys []string, err error) error {
+	if len(keys) == 1 {
+		return rc.WrapError(command, keys[0], err)
+	}
+	if err == nil || err == Nil || err == ErrCacheMiss {
+		return err
+	}
+	return fmt.Errorf("redis %s on keys %q: %w", command, keys, err)
+}
+
 // Ping 测试连接
 func (rc *RedisClient) Ping() bool {
-	ping := rc.Runner().Ping()
-	if strings.Contains(ping.String(),"PONG") && ping.Err() == redis.Nil {
-		return true
+	cmd := rc.Runner().Ping(rc.ctx)
+	if cmd.Err() != nil {
+		return false
 	}
-	return true
+	return cmd.Val() == "PONG"
 }
 
 // Expire 延期 返回bool
-func (rc RedisClient) Expire(key string, duration time.Duration) *Outcome {
+func (rc *RedisClient) Expire(key string, duration time.Duration) *Outcome {
+	return rc.ExpireCtx(rc.ctx, key, duration)
+}
+
+// ExpireCtx 延期 支持传入context 返回bool
+func (rc *RedisClient) ExpireCtx(ctx context.Context, key string, duration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Expire(rc.context(ctx), hook, duration)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ExpireAt 在指定时间过期 返回bool
+func (rc *RedisClient) ExpireAt(key string, t time.Time) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ExpireAt(rc.ctx, hook, t)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// PExpire 毫秒精度延期 返回bool
+func (rc *RedisClient) PExpire(key string, duration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().PExpire(rc.ctx, hook, duration)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ExpireNX key没有过期时间时才延期 需要redis 7.0以上 返回bool
+func (rc *RedisClient) ExpireNX(key string, duration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ExpireNX(rc.ctx, hook, duration)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ExpireXX key已有过期时间时才延期 需要redis 7.0以上 返回bool
+func (rc *RedisClient) ExpireXX(key string, duration time.Duration) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().Expire(hook, duration)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().ExpireXX(rc.ctx, hook, duration)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ExpireGT 新的过期时间大于当前值时才延期 需要redis 7.0以上 返回bool
+func (rc *RedisClient) ExpireGT(key string, duration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ExpireGT(rc.ctx, hook, duration)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ExpireLT 新的过期时间小于当前值时才延期 需要redis 7.0以上 返回bool
+func (rc *RedisClient) ExpireLT(key string, duration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ExpireLT(rc.ctx, hook, duration)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// TTL 获取剩余过期时间 返回time.Duration
+func (rc *RedisClient) TTL(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().TTL(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// PTTL 获取毫秒精度的剩余过期时间 返回time.Duration
+func (rc *RedisClient) PTTL(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().PTTL(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// Persist 移除过期时间 返回bool
+func (rc *RedisClient) Persist(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Persist(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // Get 获取值 返回string
 func (rc *RedisClient) Get(key string) *Outcome {
+	return rc.GetCtx(rc.ctx, key)
+}
+
+// GetCtx 获取值 支持传入context 返回string
+func (rc *RedisClient) GetCtx(ctx context.Context, key string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().Get(hook)
-	return rc.Outcome(cmd.Val(),cmd.Err())
+	if value, ok := rc.local.get(hook); ok {
+		return rc.Outcome(value, nil)
+	}
+	cmd := rc.Runner().Get(rc.context(ctx), hook)
+	if cmd.Err() == nil {
+		rc.local.set(hook, cmd.Val())
+	}
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GetWithTTL 通过pipeline一次往返获取值和剩余过期时间 值的 Outcome 返回string
+// key不存在时值的 IsNil 为true 没有过期时间时ttl为-1 err只表示命令执行失败
+func (rc *RedisClient) GetWithTTL(key string) (*Outcome, time.Duration, error) {
+	hook := rc.GetKey(key)
+	pipe := rc.Runner().Pipeline()
+	get := pipe.Get(rc.ctx, hook)
+	ttl := pipe.TTL(rc.ctx, hook)
+	_, _ = pipe.Exec(rc.ctx)
+	value := rc.keyOutcome(key, get.Val(), get)
+	if value.Error != nil && !value.IsNil() {
+		return value, 0, value.Error
+	}
+	if err := ttl.Err(); err != nil {
+		return value, 0, rc.WrapError(ttl.Name(), key, err)
+	}
+	return value, ttl.Val(), nil
+}
+
+// GetOrSet 缓存未命中时调用loader加载并写入缓存 返回string
+// loader出错或序列化失败时不写缓存并返回该错误 loader返回nil时视为未命中且不写缓存
+// 写缓存失败时Error为写入错误 Primordial仍为加载到的值
+// 开启压缩时缓存中为压缩后的内容 返回值仍为未压缩的序列化结果
+func (rc *RedisClient) GetOrSet(key string, ttl time.Duration, loader func() (interface{}, error)) *Outcome {
+	if outcome := rc.Get(key); !outcome.IsNil() {
+		return outcome
+	}
+	// 同一个key并发未命中时只调用一次loader 结果共享给所有等待者
+	shared, _, _ := rc.group.Do(rc.GetKey(key), func() (interface{}, error) {
+		value, err := loader()
+		if err != nil {
+			return rc.Outcome(nil, err), nil
+		}
+		if value == nil {
+			return rc.Outcome(nil, Nil), nil
+		}
+		data, ok, err := rc.marshal(value)
+		if err != nil {
+			return rc.Outcome(nil, err), nil
+		}
+		encoded, stored := fmt.Sprint(value), value
+		if ok {
+			encoded, stored = string(data), string(rc.compress(data))
+		}
+		outcome := rc.Outcome(encoded, nil)
+		outcome.Error = rc.Set(key, stored, ttl).Error
+		return outcome, nil
+	})
+	outcome := *shared.(*Outcome)
+	return &outcome
 }
 
 // GetSet key不存在则set 返回string
 func (rc *RedisClient) GetSet(key string, value interface{}) *Outcome  {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().GetSet(hook, value)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().GetSet(rc.ctx, hook, value)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// getDelScript 不支持GETDEL的服务器上获取并删除
+var getDelScript = redis.NewScript(`
+local value = redis.call("GET", KEYS[1])
+if value then
+	redis.call("DEL", KEYS[1])
+end
+return value
+`)
+
+// GetDel 原子地获取值并删除key 服务器低于6.2时使用lua脚本实现 返回string
+func (rc *RedisClient) GetDel(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GetDel(rc.ctx, hook)
+	if err := cmd.Err(); err != nil && strings.HasPrefix(err.Error(), "ERR unknown command") {
+		script := getDelScript.Run(rc.ctx, rc.Runner(), []string{hook})
+		rc.invalidate(script.Err(), key)
+		return rc.keyOutcome(key, script.Val(), script)
+	}
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GetEx 获取值并重新设置过期时间 expiration为0时移除过期时间 需要redis 6.2及以上 返回string
+func (rc *RedisClient) GetEx(key string, expiration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GetEx(rc.ctx, hook, rc.Drift(expiration))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // Set set值 返回string
 func (rc *RedisClient) Set(key string,value interface{},expiration time.Duration) *Outcome {
+	return rc.SetCtx(rc.ctx, key, value, expiration)
+}
+
+// SetCtx set值 支持传入context 返回string
+func (rc *RedisClient) SetCtx(ctx context.Context, key string,value interface{},expiration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Set(rc.context(ctx), hook, rc.GetValue(value), rc.Drift(expiration))
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+
+// SetRaw 不做序列化直接set值 适用于已经序列化好的内容 返回string
+func (rc *RedisClient) SetRaw(key string,value interface{},expiration time.Duration) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().Set(hook, rc.GetValue(value), rc.Drift(expiration))
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().Set(rc.ctx, hook, value, rc.Drift(expiration))
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
+// SetDefault 使用 Options.DefaultTTL 作为过期时间set值 DefaultTTL为0时不过期 返回string
+func (rc *RedisClient) SetDefault(key string,value interface{}) *Outcome {
+	return rc.Set(key, value, rc.opt.DefaultTTL)
+}
 
 // SetNX setNx 返回bool
 func (rc *RedisClient) SetNX(key string,value interface{},expiration time.Duration) *Outcome {
+	return rc.SetNXCtx(rc.ctx, key, value, expiration)
+}
+
+// SetNXCtx setNx 支持传入context 返回bool
+func (rc *RedisClient) SetNXCtx(ctx context.Context, key string,value interface{},expiration time.Duration) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().SetNX(hook, rc.GetValue(value), rc.Drift(expiration))
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().SetNX(rc.context(ctx), hook, rc.GetValue(value), rc.Drift(expiration))
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SetXX key存在时才set 返回bool
+func (rc *RedisClient) SetXX(key string,value interface{},expiration time.Duration) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SetXX(rc.ctx, hook, rc.GetValue(value), rc.Drift(expiration))
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SetKeepTTL 更新值并保留原有的过期时间 需要redis 6.0及以上 返回string
+func (rc *RedisClient) SetKeepTTL(key string,value interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Set(rc.ctx, hook, rc.GetValue(value), redis.KeepTTL)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// Append 追加字符串 返回追加后的长度int64
+func (rc *RedisClient) Append(key string, value string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Append(rc.ctx, hook, value)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GetRange 获取子串 返回string
+func (rc *RedisClient) GetRange(key string, start, end int64) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GetRange(rc.ctx, hook, start, end)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SetRange 从offset开始覆盖写入 返回写入后的长度int64
+func (rc *RedisClient) SetRange(key string, offset int64, value string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SetRange(rc.ctx, hook, offset, value)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // Del 删除key 返回int64
 func (rc *RedisClient) Del(keys ...string) *Outcome {
-	hooks := rc.GetKeys(keys)
-	cmd := rc.Runner().Del(hooks...)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	return rc.DelCtx(rc.ctx, keys...)
+}
+
+// DelCtx 删除key 支持传入context 返回int64
+func (rc *RedisClient) DelCtx(ctx context.Context, keys ...string) *Outcome {
+	hooks := rc.getHooks(keys)
+	cmd := rc.Runner().Del(rc.context(ctx), hooks...)
+	rc.invalidate(cmd.Err(), keys...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// Unlink 异步删除key 不阻塞服务器 返回int64
+func (rc *RedisClient) Unlink(keys ...string) *Outcome {
+	hooks := rc.getHooks(keys)
+	cmd := rc.Runner().Unlink(rc.ctx, hooks...)
+	rc.invalidate(cmd.Err(), keys...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// Rename 重命名key 返回string
+// 集群模式下两个key必须在同一个slot 可以使用 {tag} 形式的key 否则返回 ErrCrossSlot
+func (rc *RedisClient) Rename(oldKey, newKey string) *Outcome {
+	cmd := rc.Runner().Rename(rc.ctx, rc.GetKey(oldKey), rc.GetKey(newKey))
+	rc.invalidate(cmd.Err(), oldKey, newKey)
+	return rc.Outcome(cmd.Val(), rc.wrapKeysError(cmd.Name(), []string{oldKey, newKey}, crossSlot(cmd.Err())))
+}
+
+// RenameNX newKey不存在时才重命名 返回bool 集群模式下的限制同 Rename
+func (rc *RedisClient) RenameNX(oldKey, newKey string) *Outcome {
+	cmd := rc.Runner().RenameNX(rc.ctx, rc.GetKey(oldKey), rc.GetKey(newKey))
+	rc.invalidate(cmd.Err(), oldKey, newKey)
+	return rc.Outcome(cmd.Val(), rc.wrapKeysError(cmd.Name(), []string{oldKey, newKey}, crossSlot(cmd.Err())))
+}
+
+// Type 获取key的类型 string/list/hash/set/zset/stream 不存在时为none 返回string
+func (rc *RedisClient) Type(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Type(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// Dump 获取key序列化后的内容 返回string
+func (rc *RedisClient) Dump(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Dump(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// Restore 使用 Dump 的内容还原key ttl为0时不过期 返回string
+func (rc *RedisClient) Restore(key string, ttl time.Duration, payload string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().Restore(rc.ctx, hook, ttl, payload)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // Exists 判断存在多少个键 返回int64
 func (rc *RedisClient) Exists(keys ...string) *Outcome {
-	hooks := rc.GetKeys(keys)
-	cmd := rc.Runner().Exists(hooks...)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	return rc.ExistsCtx(rc.ctx, keys...)
+}
+
+// ExistsCtx 判断存在多少个键 支持传入context 返回int64
+func (rc *RedisClient) ExistsCtx(ctx context.Context, keys ...string) *Outcome {
+	hooks := rc.getHooks(keys)
+	cmd := rc.Runner().Exists(rc.context(ctx), hooks...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// ExistsMap 通过pipeline逐个判断key是否存在 返回以原始key为键的结果 集群模式下key可以不在同一个slot
+func (rc *RedisClient) ExistsMap(keys ...string) (map[string]bool, error) {
+	pipe := rc.Runner().Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i := range keys {
+		cmds[i] = pipe.Exists(rc.ctx, rc.GetKey(keys[i]))
+	}
+	_, _ = pipe.Exec(rc.ctx)
+	exists := make(map[string]bool, len(keys))
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			return nil, rc.WrapError(cmd.Name(), keys[i], err)
+		}
+		exists[keys[i]] = cmd.Val() > 0
+	}
+	return exists, nil
 }
 
 // Decr 自减1 返回int64
 func (rc *RedisClient) Decr(key string) *Outcome {
+	return rc.DecrCtx(rc.ctx, key)
+}
+
+// DecrCtx 自减1 支持传入context 返回int64
+func (rc *RedisClient) DecrCtx(ctx context.Context, key string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().Decr(hook)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().Decr(rc.context(ctx), hook)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // DecrBy 自减多 返回int64
-func (rc RedisClient) DecrBy(key string, decrement int64) *Outcome {
+func (rc *RedisClient) DecrBy(key string, decrement int64) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().DecrBy(hook, decrement)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().DecrBy(rc.ctx, hook, decrement)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 
 // Incr 自减1  返回int64
 func (rc *RedisClient) Incr(key string) *Outcome {
+	return rc.IncrCtx(rc.ctx, key)
+}
+
+// IncrCtx 自减1 支持传入context 返回int64
+func (rc *RedisClient) IncrCtx(ctx context.Context, key string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().Incr(hook)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().Incr(rc.context(ctx), hook)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // IncrBy 自减多  返回int64
-func (rc RedisClient) IncrBy(key string, decrement int64) *Outcome {
+func (rc *RedisClient) IncrBy(key string, decrement int64) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().IncrBy(rc.ctx, hook, decrement)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// IncrByFloat 浮点数自增 返回float64
+func (rc *RedisClient) IncrByFloat(key string, incr float64) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().IncrBy(hook, decrement)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().IncrByFloat(rc.ctx, hook, incr)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // MGet 批量get 返回[]interface{}
 func (rc *RedisClient) MGet(keys ...string) *Outcome {
-	hooks := rc.GetKeys(keys)
-	cmd := rc.Runner().MGet(hooks...)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	return rc.MGetCtx(rc.ctx, keys...)
+}
+
+// MGetCtx 批量get 支持传入context 返回[]interface{}
+func (rc *RedisClient) MGetCtx(ctx context.Context, keys ...string) *Outcome {
+	hooks := rc.getHooks(keys)
+	cmd := rc.Runner().MGet(rc.context(ctx), hooks...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// MGetMap 批量get 返回以原始key为键的结果 不存在的key对应的结果 IsNil 为true
+// 与 GetMany 相同 通过pipeline逐个GET 集群模式下key可以不在同一个slot
+func (rc *RedisClient) MGetMap(keys ...string) (map[string]*Outcome, error) {
+	return rc.GetMany(keys...)
 }
 
 // MSet 批量set 返回string
 func (rc *RedisClient) MSet(pairs ...interface{}) *Outcome {
 	kvs := make([]interface{},0, len(pairs)/2 + 1)
+	keys := make([]string, 0, len(pairs)/2)
+	hooks := make([]string, 0, len(pairs)/2)
 	for i := 0; i < len(pairs); i++ {
-		kvs = append(kvs, rc.GetKey(pairs[i]))
+		hook := rc.GetKey(pairs[i])
+		keys = append(keys, keyPart(pairs[i]))
+		hooks = append(hooks, hook)
+		kvs = append(kvs, hook)
 		kvs = append(kvs, rc.GetValue(pairs[i+1]))
 		i++
 	}
-	cmd := rc.Runner().MSet(pairs)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().MSet(rc.ctx, kvs...)
+	rc.invalidateHooks(cmd.Err(), hooks...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// SetMany 通过pipeline批量set 每个值单独序列化并设置相同的过期时间 返回string
+// MSET不支持过期时间 集群模式下key可以不在同一个slot 有失败时返回第一个错误
+func (rc *RedisClient) SetMany(pairs map[string]interface{}, ttl time.Duration) *Outcome {
+	keys := make([]string, 0, len(pairs))
+	pipe := rc.Runner().Pipeline()
+	cmds := make([]*redis.StatusCmd, 0, len(pairs))
+	writes := make([]localWrite, 0, len(pairs))
+	for key, value := range pairs {
+		cmd := pipe.Set(rc.ctx, rc.GetKey(key), rc.GetValue(value), rc.Drift(ttl))
+		keys = append(keys, key)
+		cmds = append(cmds, cmd)
+		writes = append(writes, localWrite{keys: []string{key}, cmd: cmd})
+	}
+	_, _ = pipe.Exec(rc.ctx)
+	rc.invalidateWrites(writes)
+	for i, cmd := range cmds {
+		if cmd.Err() != nil {
+			return rc.keyOutcome(keys[i], nil, cmd)
+		}
+	}
+	return rc.Outcome("OK", nil)
+}
+
+// GetMany 通过pipeline批量get 返回以原始key为键的结果 值可以直接 Unmarshal
+// 不存在的key对应的结果 IsNil 为true 集群模式下key可以不在同一个slot
+func (rc *RedisClient) GetMany(keys ...string) (map[string]*Outcome, error) {
+	pipe := rc.Runner().Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i := range keys {
+		cmds[i] = pipe.Get(rc.ctx, rc.GetKey(keys[i]))
+	}
+	_, _ = pipe.Exec(rc.ctx)
+	outcomes := make(map[string]*Outcome, len(keys))
+	for i, cmd := range cmds {
+		outcome := rc.keyOutcome(keys[i], cmd.Val(), cmd)
+		if outcome.Error != nil && !outcome.IsNil() {
+			return nil, outcome.Error
+		}
+		outcomes[keys[i]] = outcome
+	}
+	return outcomes, nil
 }
 
 // HGet 获取hash的值 返回string
-func (rc *RedisClient) HGet(key string,field string) *Outcome  {
+func (rc *RedisClient) HGet(key string,field string) *Outcome {
+	return rc.HGetCtx(rc.ctx, key, field)
+}
+
+// HGetCtx 获取hash的值 支持传入context 返回string
+func (rc *RedisClient) HGetCtx(ctx context.Context, key string,field string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HGet(hook, field)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HGet(rc.context(ctx), hook, field)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // HSet 给hash设置值 返回bool
 func (rc *RedisClient) HSet(key, field string, value interface{}) *Outcome {
+	return rc.HSetCtx(rc.ctx, key, field, value)
+}
+
+// HSetCtx 给hash设置值 支持传入context 返回bool
+func (rc *RedisClient) HSetCtx(ctx context.Context, key, field string, value interface{}) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HSet(hook, field, rc.GetValue(value))
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HSet(rc.context(ctx), hook, field, rc.GetValue(value))
+	return rc.keyOutcome(key, cmd.Val() > 0, cmd)
 }
 
 // HDel 删除hash的key 返回int64
 func (rc *RedisClient) HDel(key string, fields ...string) *Outcome {
+	return rc.HDelCtx(rc.ctx, key, fields...)
+}
+
+// HDelCtx 删除hash的key 支持传入context 返回int64
+func (rc *RedisClient) HDelCtx(ctx context.Context, key string, fields ...string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().HDel(rc.context(ctx), hook, fields...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// HSetNX field不存在时才设置 返回bool
+func (rc *RedisClient) HSetNX(key, field string, value interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().HSetNX(rc.ctx, hook, field, rc.GetValue(value))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// HMSet 批量给hash设置值 返回bool
+func (rc *RedisClient) HMSet(key string, fields map[string]interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	values := make(map[string]interface{}, len(fields))
+	for field, value := range fields {
+		values[field] = rc.GetValue(value)
+	}
+	cmd := rc.Runner().HMSet(rc.ctx, hook, values)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// HMGet 批量获取hash的值 按fields顺序返回[]interface{}
+func (rc *RedisClient) HMGet(key string, fields ...string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HDel(hook, fields...)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HMGet(rc.ctx, hook, fields...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // HExists 判断hash是否存在field 返回bool
 func (rc *RedisClient) HExists(key string,field string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HExists(hook, field)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HExists(rc.ctx, hook, field)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 
 // HGetAll 获取hash的所有值 返回map[string]string
 func (rc *RedisClient) HGetAll(key string) *Outcome {
+	return rc.HGetAllCtx(rc.ctx, key)
+}
+
+// HGetAllCtx 获取hash的所有值 支持传入context 返回map[string]string
+func (rc *RedisClient) HGetAllCtx(ctx context.Context, key string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HGetAll(hook)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HGetAll(rc.context(ctx), hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // HKeys 获取hash的所有key 返回[]string
 func (rc *RedisClient) HKeys(key string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HKeys(hook)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HKeys(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// HRandField 随机获取hash的字段 count为负数时可能重复 需要redis 6.2及以上
+// withValues为false时返回[]string withValues为true时返回map[string]string 重复的字段只保留一个
+func (rc *RedisClient) HRandField(key string, count int, withValues bool) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().HRandField(rc.ctx, hook, count, withValues)
+	if !withValues || cmd.Err() != nil {
+		return rc.keyOutcome(key, cmd.Val(), cmd)
+	}
+	pairs := cmd.Val()
+	fields := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields[pairs[i]] = pairs[i+1]
+	}
+	return rc.Outcome(fields, nil)
 }
 
 // HLen 获取hash的长度 返回int64
 func (rc *RedisClient) HLen(key string) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HLen(hook)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HLen(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 
 // HIncrBy 增长hash的value 返回int64
 func (rc *RedisClient) HIncrBy(key string,field string,incr int64) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HIncrBy(hook,field, incr)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HIncrBy(rc.ctx, hook,field, incr)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
 
 // HIncrByFloat 增长hash的value 返回float64
 func (rc *RedisClient) HIncrByFloat(key, field string, incr float64) *Outcome {
 	hook := rc.GetKey(key)
-	cmd := rc.Runner().HIncrByFloat(hook,field, incr)
-	return rc.Outcome(cmd.Val(), cmd.Err())
+	cmd := rc.Runner().HIncrByFloat(rc.ctx, hook,field, incr)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
 }
\ No newline at end of file
@@ -0,0 +1,1685 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+	"github.com/go-redis/redis/v8"
+)
+
+// rejectHook 让指定命令在发送前失败
+type rejectHook struct {
+	name string
+	err  error
+}
+
+func (h rejectHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if cmd.Name() == h.name {
+		return ctx, h.err
+	}
+	return ctx, nil
+}
+
+func (h rejectHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h rejectHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h rejectHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestGetOrSetHit(t *testing.T) {
+	initTestRedis(t)
+	rc := GetRedis()
+	if err := rc.Set("user", "cached", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	got, err := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		t.Fatal("loader called on hit")
+		return nil, nil
+	}).GetString()
+	if err != nil || got != "cached" {
+		t.Fatalf("GetOrSet hit = %q, %v", got, err)
+	}
+}
+
+func TestGetOrSetMissPopulatesCache(t *testing.T) {
+	mr := initTestRedis(t)
+	rc := GetRedis()
+	var u testUser
+	if err := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		return testUser{Name: "bob", Age: 3}, nil
+	}).Unmarshal(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u != (testUser{Name: "bob", Age: 3}) {
+		t.Fatalf("loaded %+v", u)
+	}
+	if got, _ := mr.Get(rc.GetKey("user")); got != `{"name":"bob","age":3}` {
+		t.Fatalf("cached %q", got)
+	}
+	if mr.TTL(rc.GetKey("user")) != time.Minute {
+		t.Fatalf("ttl = %s", mr.TTL(rc.GetKey("user")))
+	}
+}
+
+func TestGetOrSetLoaderError(t *testing.T) {
+	mr := initTestRedis(t)
+	rc := GetRedis()
+	boom := errors.New("boom")
+	err := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		return nil, boom
+	}).Error
+	if !errors.Is(err, boom) {
+		t.Fatalf("loader error not propagated: %v", err)
+	}
+	if mr.Exists(rc.GetKey("user")) {
+		t.Fatal("loader error should not be cached")
+	}
+}
+
+func TestGetOrSetNilLoaderResultIsMiss(t *testing.T) {
+	mr := initTestRedis(t)
+	rc := GetRedis()
+	outcome := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		return nil, nil
+	})
+	if !outcome.IsNil() {
+		t.Fatalf("nil loader result = %v, want miss", outcome.Error)
+	}
+	if mr.Exists(rc.GetKey("user")) {
+		t.Fatal("nil loader result should not be cached")
+	}
+	if got := rc.GetValue(nil); got != nil {
+		t.Fatalf("GetValue(nil) = %v", got)
+	}
+}
+
+func TestGetOrSetLoaderRunsOnceUnderStampede(t *testing.T) {
+	rc, _ := newTestClient(t)
+	var calls int32
+	release := make(chan struct{})
+	loader := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "loaded", nil
+	}
+
+	const n = 50
+	var started, done sync.WaitGroup
+	results := make([]string, n)
+	errs := make([]error, n)
+	started.Add(n)
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			results[i], errs[i] = rc.GetOrSet("cold", time.Minute, loader).GetString()
+		}(i)
+	}
+	started.Wait()
+	// 给所有goroutine足够时间进入singleflight后再放行loader
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("loader ran %d times", got)
+	}
+	for i := range results {
+		if errs[i] != nil || results[i] != "loaded" {
+			t.Fatalf("caller %d got %q, %v", i, results[i], errs[i])
+		}
+	}
+}
+
+func TestGetOrSetMarshalError(t *testing.T) {
+	mr := initTestRedis(t)
+	rc := GetRedis()
+	outcome := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		return map[string]interface{}{"ch": make(chan int)}, nil
+	})
+	var unsupported *json.UnsupportedTypeError
+	if !errors.As(outcome.Error, &unsupported) {
+		t.Fatalf("marshal error = %v", outcome.Error)
+	}
+	if outcome.Primordial != nil {
+		t.Fatalf("Primordial = %v, want nil", outcome.Primordial)
+	}
+	if mr.Exists(rc.GetKey("user")) {
+		t.Fatal("unmarshalable value should not be cached")
+	}
+}
+
+func TestGetOrSetReportsWriteError(t *testing.T) {
+	initTestRedis(t)
+	rc := GetRedis()
+	readonly := errors.New("READONLY")
+	rc.addHook(rejectHook{name: "set", err: readonly})
+	outcome := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		return testUser{Name: "bob", Age: 3}, nil
+	})
+	if !errors.Is(outcome.Error, readonly) {
+		t.Fatalf("write error = %v", outcome.Error)
+	}
+	if outcome.Primordial != `{"name":"bob","age":3}` {
+		t.Fatalf("Primordial = %v", outcome.Primordial)
+	}
+}
+
+func TestFailedGetErrorNamesCommandAndKey(t *testing.T) {
+	rc, mr := newTestClient(t)
+	mr.HSet(rc.GetKey("profile"), "name", "bob")
+	err := rc.Get("profile").Error
+	if err == nil {
+		t.Fatal("GET on a hash should fail")
+	}
+	if !strings.Contains(err.Error(), "get") || !strings.Contains(err.Error(), `"profile"`) {
+		t.Fatalf("error %q should name the command and the logical key", err)
+	}
+	if strings.Contains(err.Error(), rc.GetKey("")) {
+		t.Fatalf("error %q should use the logical key", err)
+	}
+	if errors.Unwrap(err) == nil {
+		t.Fatal("the server error should stay wrapped")
+	}
+	if err := rc.Get("missing").Error; err != ErrCacheMiss {
+		t.Fatalf("misses should not be wrapped, got %v", err)
+	}
+}
+
+func TestMultiKeyErrorsNameCommandAndKeys(t *testing.T) {
+	rc, mr := newTestClient(t)
+	mr.SetError("boom")
+	_, mgetMapErr := rc.MGetMap("a", "b")
+	pipelined := rc.Pipeline().Get("a").Del("a", "b").Exec()
+	for _, c := range []struct {
+		err  error
+		want string
+	}{
+		{rc.Del("a", "b").Error, `redis del on keys ["a" "b"]`},
+		{rc.Unlink("a", "b").Error, `redis unlink on keys ["a" "b"]`},
+		{rc.Exists("a").Error, `redis exists on key "a"`},
+		{rc.MGet("a", "b").Error, `redis mget on keys ["a" "b"]`},
+		{mgetMapErr, `redis get on key "a"`},
+		{rc.MSet("a", 1, "b", 2).Error, `redis mset on keys ["a" "b"]`},
+		{rc.Rename("a", "b").Error, `redis rename on keys ["a" "b"]`},
+		{rc.RenameNX("a", "b").Error, `redis renamenx on keys ["a" "b"]`},
+		{rc.Publish("news", "hi").Error, `redis publish on key "news"`},
+		{rc.PFCount("a", "b").Error, `redis pfcount on keys ["a" "b"]`},
+		{rc.PFMerge("dest", "a").Error, `redis pfmerge on keys ["dest" "a"]`},
+		{rc.Eval("return 1", []string{"a"}).Error, `on key "a"`},
+		{rc.LMove("a", "b", "LEFT", "RIGHT").Error, `redis lmove on keys ["a" "b"]`},
+		{rc.RPopLPush("a", "b").Error, `redis rpoplpush on keys ["a" "b"]`},
+		{pipelined[0].Error, `redis get on key "a"`},
+		{pipelined[1].Error, `redis del on keys ["a" "b"]`},
+	} {
+		if c.err == nil || !strings.Contains(c.err.Error(), c.want) {
+			t.Fatalf("error %v should contain %s", c.err, c.want)
+		}
+		if strings.Contains(c.err.Error(), rc.GetKey("")) {
+			t.Fatalf("error %q should use logical keys", c.err)
+		}
+	}
+}
+
+func TestGetOrSetReturnsUncompressedValue(t *testing.T) {
+	rc, mr := newTestClient(t, func(opt *Options) {
+		opt.Compression = CompressionGzip
+		opt.CompressThreshold = 1
+	})
+	got, err := rc.GetOrSet("user", time.Minute, func() (interface{}, error) {
+		return testUser{Name: "bob", Age: 3}, nil
+	}).GetString()
+	if err != nil || got != `{"name":"bob","age":3}` {
+		t.Fatalf("GetOrSet = %q, %v", got, err)
+	}
+	if stored, _ := mr.Get(rc.GetKey("user")); len(stored) < 2 || stored[0] != compressMagic {
+		t.Fatalf("cached value should be compressed, got %q", stored)
+	}
+}
+
+// registerDump miniredis不支持DUMP/RESTORE 这里注册一个只处理字符串的简化实现
+func registerDump(t *testing.T, mr *miniredis.Miniredis) {
+	t.Helper()
+	const prefix = "dump:"
+	err := mr.Server().Register("DUMP", func(c *server.Peer, cmd string, args []string) {
+		value, err := mr.Get(args[0])
+		if err != nil {
+			c.WriteNull()
+			return
+		}
+		c.WriteBulk(prefix + value)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mr.Server().Register("RESTORE", func(c *server.Peer, cmd string, args []string) {
+		if mr.Exists(args[0]) {
+			c.WriteError("BUSYKEY Target key name already exists.")
+			return
+		}
+		if !strings.HasPrefix(args[2], prefix) {
+			c.WriteError("ERR DUMP payload version or checksum are wrong")
+			return
+		}
+		_ = mr.Set(args[0], strings.TrimPrefix(args[2], prefix))
+		if ms, _ := strconv.Atoi(args[1]); ms > 0 {
+			mr.SetTTL(args[0], time.Duration(ms)*time.Millisecond)
+		}
+		c.WriteOK()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBinaryKeyRoundTrip(t *testing.T) {
+	rc, mr := newTestClient(t)
+	raw := []byte{0x00, 0xff, 'k', 0x80, ':'}
+	if err := rc.MSet(raw, "v").Error; err != nil {
+		t.Fatal(err)
+	}
+	want := rc.GetKey(Null) + string(raw)
+	if keys := mr.Keys(); len(keys) != 1 || keys[0] != want {
+		t.Fatalf("stored keys %q, want %q", keys, want)
+	}
+	keys, err := rc.ScanKeys("*", 10)
+	if err != nil || len(keys) != 1 || !bytes.Equal([]byte(keys[0]), raw) {
+		t.Fatalf("ScanKeys = %q, %v", keys, err)
+	}
+	if got, _ := rc.Get(keys[0]).GetString(); got != "v" {
+		t.Fatalf("Get by the scanned key = %q", got)
+	}
+}
+
+func TestKeyPartEncodings(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	shanghai := at.In(time.FixedZone("CST", 8*3600))
+	cases := []struct {
+		raw  interface{}
+		want string
+	}{
+		{"s", "s"},
+		{[]byte("b"), "b"},
+		{42, "42"},
+		{true, "true"},
+		{at, "2024-01-02T03:04:05.000000006Z"},
+		{shanghai, "2024-01-02T03:04:05.000000006Z"},
+		{time.Second, "1s"},
+		{testUser{Name: "a", Age: 1}, `{"name":"a","age":1}`},
+		{&testUser{Name: "a", Age: 1}, `{"name":"a","age":1}`},
+	}
+	for _, c := range cases {
+		if got := keyPart(c.raw); got != c.want {
+			t.Errorf("keyPart(%#v) = %q, want %q", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestInitRedisClientRejectsConflictingOptions(t *testing.T) {
+	mr := initTestRedis(t)
+	first := GetRedis()
+	same := &Options{AppName: "app", NameSpace: "test", Addr: []string{mr.Addr()}, DriftWindow: -1}
+	if err := InitRedisClient(same); err != nil {
+		t.Fatalf("equal options should be accepted: %v", err)
+	}
+	if err := InitRedisClient(first.opt); err != nil {
+		t.Fatalf("the same options pointer should be accepted: %v", err)
+	}
+	err := InitRedisClient(&Options{AppName: "other", NameSpace: "test", Addr: []string{"127.0.0.1:1"}})
+	if !errors.Is(err, ErrAlreadyInitialized) {
+		t.Fatalf("conflicting options: %v, want ErrAlreadyInitialized", err)
+	}
+	for _, want := range []string{`"app"`, `"test"`, mr.Addr()} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+	if GetRedis() != first {
+		t.Fatal("a rejected init must keep the existing client")
+	}
+}
+
+func TestInitRedisClientRetriesAfterFailure(t *testing.T) {
+	if err := InitRedisClient(&Options{AppName: "app"}); err == nil {
+		t.Fatal("options without addr should fail")
+	}
+	if GetRedis() != nil {
+		t.Fatal("a failed init must not set the global client")
+	}
+	initTestRedis(t)
+	if GetRedis() == nil {
+		t.Fatal("init should succeed after a failed attempt")
+	}
+}
+
+func TestInitRedisClientConcurrent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	opt := &Options{AppName: "app", NameSpace: "test", Addr: []string{mr.Addr()}}
+	var wg sync.WaitGroup
+	clients := make([]*RedisClient, 8)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := InitRedisClient(opt); err != nil {
+				t.Error(err)
+			}
+			clients[i] = GetRedis()
+		}(i)
+	}
+	wg.Wait()
+	t.Cleanup(func() {
+		if rc := GetRedis(); rc != nil {
+			_ = rc.Close()
+		}
+	})
+	for _, rc := range clients {
+		if rc == nil || rc != clients[0] {
+			t.Fatal("concurrent inits should share one global client")
+		}
+	}
+}
+
+type testUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// newTestClient 启动一个miniredis并创建客户端 关闭摆动以便断言过期时间
+func newTestClient(t *testing.T, opts ...func(*Options)) (*RedisClient, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	opt := &Options{
+		AppName:     "app",
+		NameSpace:   "test",
+		Addr:        []string{mr.Addr()},
+		DriftWindow: -1,
+	}
+	for _, fn := range opts {
+		fn(opt)
+	}
+	rc, err := NewRedisClient(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = rc.Close() })
+	return rc, mr
+}
+
+func TestMSetNamespacedKeys(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if err := rc.MSet("a", "1", "b", "2").Error; err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"app:test:a": "1", "app:test:b": "2"} {
+		got, err := mr.Get(key)
+		if err != nil || got != want {
+			t.Fatalf("%s = %q, %v; want %q", key, got, err, want)
+		}
+	}
+	if mr.Exists("a") || mr.Exists("b") {
+		t.Fatal("raw keys must not be written")
+	}
+}
+
+func TestMSetSerializesStructs(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if err := rc.MSet("u", testUser{Name: "tom", Age: 3}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mr.Get("app:test:u"); got != `{"name":"tom","age":3}` {
+		t.Fatalf("stored %q", got)
+	}
+	var u testUser
+	if err := rc.Get("u").Unmarshal(&u); err != nil || u.Name != "tom" || u.Age != 3 {
+		t.Fatalf("got %+v, %v", u, err)
+	}
+}
+
+func TestPingReportsUnreachableServer(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if !rc.Ping() {
+		t.Fatal("ping to a running server should succeed")
+	}
+	mr.Close()
+	if rc.Ping() {
+		t.Fatal("ping to a closed server should fail")
+	}
+}
+
+func TestDriftSpreadsTTLAcrossWindow(t *testing.T) {
+	rc, mr := newTestClient(t, func(opt *Options) { opt.DriftWindow = 0 })
+	ttl := 10 * time.Second
+	min, max := time.Duration(1<<62), time.Duration(0)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if err := rc.Set(key, "v", ttl).Error; err != nil {
+			t.Fatal(err)
+		}
+		got := mr.TTL("app:test:" + key)
+		if got < ttl || got >= ttl+DefaultDriftWindow {
+			t.Fatalf("ttl %s outside [%s, %s)", got, ttl, ttl+DefaultDriftWindow)
+		}
+		if got < min {
+			min = got
+		}
+		if got > max {
+			max = got
+		}
+	}
+	if max-min < time.Second {
+		t.Fatalf("ttls clustered within %s", max-min)
+	}
+}
+
+func TestDriftDisabled(t *testing.T) {
+	rc, _ := newTestClient(t)
+	if got := rc.Drift(time.Minute); got != time.Minute {
+		t.Fatalf("negative window should not drift, got %s", got)
+	}
+	if got := rc.Drift(0); got != 0 {
+		t.Fatalf("zero duration should stay zero, got %s", got)
+	}
+}
+
+// initTestRedis 初始化全局客户端 TimeoutLocker 等依赖 GetRedis 的功能使用
+func initTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	if err := InitRedisClient(&Options{AppName: "app", NameSpace: "test", Addr: []string{mr.Addr()}, DriftWindow: -1}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if rc := GetRedis(); rc != nil {
+			_ = rc.Close()
+		}
+	})
+	return mr
+}
+
+func TestCloseAllowsReinitialize(t *testing.T) {
+	initTestRedis(t)
+	first := GetRedis()
+	if first == nil || !first.Ping() {
+		t.Fatal("global client should be initialized")
+	}
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if GetRedis() != nil {
+		t.Fatal("close should reset the global client")
+	}
+	initTestRedis(t)
+	second := GetRedis()
+	if second == nil || second == first || !second.Ping() {
+		t.Fatal("reinitialized client should be a new working client")
+	}
+}
+
+func TestCtxCancelledFailsFast(t *testing.T) {
+	rc, _ := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rc.GetCtx(ctx, "k").Error; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if err := rc.SetCtx(ctx, "k", "v", 0).Error; !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestCtxDeadlineDoesNotBlockOnPool(t *testing.T) {
+	rc, mr := newTestClient(t, func(opt *Options) {
+		opt.PoolSize = 1
+		opt.PoolTimeout = 10 * time.Second
+	})
+	// 占用唯一的连接 返回前推入元素唤醒BLPOP并等待goroutine退出
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rc.BLPop(context.Background(), 10*time.Second, "busy")
+	}()
+	defer func() {
+		mr.Lpush(rc.GetKey("busy"), "release")
+		<-done
+	}()
+	for stats := rc.PoolStats(); stats.TotalConns != 1 || stats.IdleConns != 0; stats = rc.PoolStats() {
+		select {
+		case <-done:
+			t.Fatal("BLPOP returned before the pool was exhausted")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := rc.GetCtx(ctx, "k").Error
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("waited %s for the pool", elapsed)
+	}
+}
+
+func TestGetBytes(t *testing.T) {
+	raw := []byte{0x00, 0xff, 0x10}
+	got, err := (&Outcome{Primordial: string(raw)}).GetBytes()
+	if err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("string backed: %v, %v", got, err)
+	}
+	got, err = (&Outcome{Primordial: raw}).GetBytes()
+	if err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("byte backed: %v, %v", got, err)
+	}
+	if _, err = (&Outcome{Primordial: int64(1)}).GetBytes(); !errors.Is(err, ErrTypeMatch) {
+		t.Fatalf("got %v, want ErrTypeMatch", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("user", testUser{Name: "tom", Age: 3}, 0)
+	rc.Set("list", []int{1, 2, 3}, 0)
+	rc.Set("map", map[string]int{"a": 1}, 0)
+
+	u, err := Decode[testUser](rc.Get("user"))
+	if err != nil || u != (testUser{Name: "tom", Age: 3}) {
+		t.Fatalf("struct: %+v, %v", u, err)
+	}
+	list, err := Decode[[]int](rc.Get("list"))
+	if err != nil || len(list) != 3 || list[2] != 3 {
+		t.Fatalf("slice: %v, %v", list, err)
+	}
+	m, err := Decode[map[string]int](rc.Get("map"))
+	if err != nil || m["a"] != 1 {
+		t.Fatalf("map: %v, %v", m, err)
+	}
+	missing, err := Decode[testUser](rc.Get("missing"))
+	if !errors.Is(err, ErrCacheMiss) || missing != (testUser{}) {
+		t.Fatalf("miss: %+v, %v", missing, err)
+	}
+}
+
+func TestTTLAndPTTL(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("ttl", "v", time.Minute)
+	rc.Set("forever", "v", 0)
+
+	if d, err := rc.TTL("ttl").GetDuration(); err != nil || d != time.Minute {
+		t.Fatalf("ttl %s, %v", d, err)
+	}
+	if d, err := rc.PTTL("ttl").GetDuration(); err != nil || d <= 0 || d > time.Minute {
+		t.Fatalf("pttl %s, %v", d, err)
+	}
+	if _, err := rc.TTL("forever").GetDuration(); !errors.Is(err, ErrNoExpire) {
+		t.Fatalf("persistent key: %v, want ErrNoExpire", err)
+	}
+	if _, err := rc.TTL("missing").GetDuration(); !errors.Is(err, ErrKeyMissing) {
+		t.Fatalf("missing key: %v, want ErrKeyMissing", err)
+	}
+}
+
+func TestPersist(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("k", "v", time.Minute)
+	if ok, err := rc.Persist("k").GetBool(); err != nil || !ok {
+		t.Fatalf("persist %v, %v", ok, err)
+	}
+	if _, err := rc.TTL("k").GetDuration(); !errors.Is(err, ErrNoExpire) {
+		t.Fatalf("ttl after persist: %v, want ErrNoExpire", err)
+	}
+	if ok, _ := rc.Persist("k").GetBool(); ok {
+		t.Fatal("persisting a key without ttl should report false")
+	}
+}
+
+func TestIsNil(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("k", "v", 0)
+	if !rc.Get("missing").IsNil() {
+		t.Fatal("missing key should be nil")
+	}
+	if rc.Get("k").IsNil() {
+		t.Fatal("existing key should not be nil")
+	}
+	if !(&Outcome{Error: Nil}).IsNil() {
+		t.Fatal("redis.Nil should be nil")
+	}
+}
+
+func TestGetIntAndUint64(t *testing.T) {
+	if n, err := (&Outcome{Primordial: 7}).GetInt(); err != nil || n != 7 {
+		t.Fatalf("native int: %d, %v", n, err)
+	}
+	if n, err := (&Outcome{Primordial: int64(8)}).GetInt(); err != nil || n != 8 {
+		t.Fatalf("native int64: %d, %v", n, err)
+	}
+	if n, err := (&Outcome{Primordial: "-9"}).GetInt(); err != nil || n != -9 {
+		t.Fatalf("string int: %d, %v", n, err)
+	}
+	if _, err := (&Outcome{Primordial: "99999999999999999999"}).GetInt(); err == nil {
+		t.Fatal("overflowing string should fail")
+	}
+	if n, err := (&Outcome{Primordial: uint64(1 << 63)}).GetUint64(); err != nil || n != 1<<63 {
+		t.Fatalf("native uint64: %d, %v", n, err)
+	}
+	if n, err := (&Outcome{Primordial: "18446744073709551615"}).GetUint64(); err != nil || n != 1<<64-1 {
+		t.Fatalf("string uint64: %d, %v", n, err)
+	}
+	if _, err := (&Outcome{Primordial: "-1"}).GetUint64(); err == nil || !strings.Contains(err.Error(), "negative") {
+		t.Fatalf("negative string: %v", err)
+	}
+	if _, err := (&Outcome{Primordial: int64(-1)}).GetUint64(); err == nil {
+		t.Fatal("negative int64 should fail")
+	}
+	if _, err := (&Outcome{Primordial: 1.5}).GetInt(); !errors.Is(err, ErrTypeMatch) {
+		t.Fatalf("float: %v, want ErrTypeMatch", err)
+	}
+}
+
+func TestGetStringSlice(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("a", "1", 0)
+	rc.Set("c", "3", 0)
+	values, err := rc.MGet("a", "b", "c").GetStringSlice()
+	if err != nil || len(values) != 3 || values[0] != "1" || values[1] != "" || values[2] != "3" {
+		t.Fatalf("mget %q, %v", values, err)
+	}
+	plain, err := (&Outcome{Primordial: []string{"x"}}).GetStringSlice()
+	if err != nil || len(plain) != 1 || plain[0] != "x" {
+		t.Fatalf("plain %q, %v", plain, err)
+	}
+	if _, err := (&Outcome{Primordial: []interface{}{1}}).GetStringSlice(); !errors.Is(err, ErrTypeMatch) {
+		t.Fatalf("non string element: %v", err)
+	}
+}
+
+// newSentinelStub 启动一个只应答主节点地址查询的sentinel 主节点指向mr
+func newSentinelStub(t *testing.T, mr *miniredis.Miniredis, master string) string {
+	t.Helper()
+	srv, err := server.NewServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	host, port, _ := net.SplitHostPort(mr.Addr())
+	_ = srv.Register("SENTINEL", func(c *server.Peer, cmd string, args []string) {
+		if len(args) == 2 && strings.EqualFold(args[0], "get-master-addr-by-name") && args[1] == master {
+			c.WriteStrings([]string{host, port})
+			return
+		}
+		c.WriteLen(0)
+	})
+	return srv.Addr().String()
+}
+
+func TestSentinelChosenWhenMasterNameSet(t *testing.T) {
+	mr := miniredis.RunT(t)
+	sentinel := newSentinelStub(t, mr, "mymaster")
+	rc, err := NewRedisClient(&Options{
+		AppName:       "app",
+		NameSpace:     "test",
+		Addr:          []string{"127.0.0.1:1", "127.0.0.1:2"},
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{sentinel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if !rc.flag || rc.single == nil || rc.cluster != nil {
+		t.Fatal("failover client should be used instead of cluster")
+	}
+	if err := rc.Set("k", "v", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mr.Get("app:test:k"); got != "v" {
+		t.Fatalf("master got %q", got)
+	}
+}
+
+func TestSentinelRequiresAddrs(t *testing.T) {
+	if _, err := NewRedisClient(&Options{MasterName: "mymaster"}); err == nil {
+		t.Fatal("missing sentinel addrs should fail")
+	}
+}
+
+func TestTLSConfigPropagates(t *testing.T) {
+	cfg := &tls.Config{ServerName: "redis.example.com"}
+	single, err := NewRedisClient(&Options{Addr: []string{"127.0.0.1:1"}, TLSConfig: cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer single.Close()
+	if single.single.Options().TLSConfig != cfg {
+		t.Fatal("single client should use the configured tls config")
+	}
+
+	cluster, err := NewRedisClient(&Options{Addr: []string{"127.0.0.1:1", "127.0.0.1:2"}, UseTLS: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	if cluster.cluster.Options().TLSConfig == nil {
+		t.Fatal("UseTLS should build a default tls config for the cluster client")
+	}
+
+	plain, err := NewRedisClient(&Options{Addr: []string{"127.0.0.1:1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	if plain.single.Options().TLSConfig != nil {
+		t.Fatal("tls should be off by default")
+	}
+}
+
+func TestHMSetAndHMGet(t *testing.T) {
+	rc, mr := newTestClient(t)
+	err := rc.HMSet("h", map[string]interface{}{
+		"a": testUser{Name: "a"},
+		"b": testUser{Name: "b"},
+		"c": "plain",
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("app:test:h", "c"); got != "plain" {
+		t.Fatalf("field c = %q", got)
+	}
+	values, err := rc.HMGet("h", "c", "missing", "a").GetStringSlice()
+	if err != nil || len(values) != 3 || values[0] != "plain" || values[1] != "" {
+		t.Fatalf("hmget %q, %v", values, err)
+	}
+	var u testUser
+	if err := json.Unmarshal([]byte(values[2]), &u); err != nil || u.Name != "a" {
+		t.Fatalf("field a decoded %+v, %v", u, err)
+	}
+}
+
+func TestSetXX(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if ok, err := rc.SetXX("k", "v", 0).GetBool(); err != nil || ok {
+		t.Fatalf("missing key: %v, %v", ok, err)
+	}
+	if mr.Exists("app:test:k") {
+		t.Fatal("SetXX must not create a missing key")
+	}
+	rc.Set("k", "old", 0)
+	if ok, err := rc.SetXX("k", "new", time.Minute).GetBool(); err != nil || !ok {
+		t.Fatalf("existing key: %v, %v", ok, err)
+	}
+	if got, _ := mr.Get("app:test:k"); got != "new" {
+		t.Fatalf("value %q", got)
+	}
+	if mr.TTL("app:test:k") != time.Minute {
+		t.Fatalf("ttl %s", mr.TTL("app:test:k"))
+	}
+}
+
+func TestGettersReturnErrTypeMatch(t *testing.T) {
+	oc := &Outcome{Primordial: struct{}{}}
+	getters := map[string]func() error{
+		"GetInt64":        func() error { _, err := oc.GetInt64(); return err },
+		"GetInt":          func() error { _, err := oc.GetInt(); return err },
+		"GetUint64":       func() error { _, err := oc.GetUint64(); return err },
+		"GetString":       func() error { _, err := oc.GetString(); return err },
+		"GetBytes":        func() error { _, err := oc.GetBytes(); return err },
+		"GetFloat64":      func() error { _, err := oc.GetFloat64(); return err },
+		"GetBool":         func() error { _, err := oc.GetBool(); return err },
+		"Unmarshal":       func() error { var v interface{}; return oc.Unmarshal(&v) },
+		"GetMap":          func() error { _, err := oc.GetMap(); return err },
+		"ScanStruct":      func() error { var u testUser; return oc.ScanStruct(&u) },
+		"GetArray":        func() error { _, err := oc.GetArray(); return err },
+		"GetStringSlice":  func() error { _, err := oc.GetStringSlice(); return err },
+		"GetDuration":     func() error { _, err := oc.GetDuration(); return err },
+		"GetZSlice":       func() error { _, err := oc.GetZSlice(); return err },
+		"GetGeoLocations": func() error { _, err := oc.GetGeoLocations(); return err },
+		"GetXStreams":     func() error { _, err := oc.GetXStreams(); return err },
+	}
+	for name, get := range getters {
+		if err := get(); !errors.Is(err, ErrTypeMatch) {
+			t.Errorf("%s: %v, want ErrTypeMatch", name, err)
+		}
+	}
+	if ErrTypeMatch.Error() != TypeMatchError {
+		t.Fatal("ErrTypeMatch should keep the TypeMatchError text")
+	}
+}
+
+func TestScanStructFromHash(t *testing.T) {
+	type profile struct {
+		Name    string `redis:"name"`
+		Age     int    `json:"age"`
+		Active  bool   `redis:"active"`
+		Email   string `redis:"email"`
+		Ignored string `redis:"-"`
+	}
+	rc, _ := newTestClient(t)
+	if err := rc.HMSet("profile", map[string]interface{}{
+		"name": "bob", "age": 30, "active": true, "Ignored": "x",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	p := profile{Email: "keep@example.com"}
+	if err := rc.HGetAll("profile").ScanStruct(&p); err != nil {
+		t.Fatal(err)
+	}
+	want := profile{Name: "bob", Age: 30, Active: true, Email: "keep@example.com"}
+	if p != want {
+		t.Fatalf("got %+v, want %+v", p, want)
+	}
+}
+
+func TestScanStructRejectsBadInput(t *testing.T) {
+	type counter struct {
+		N int `redis:"n"`
+	}
+	oc := &Outcome{Primordial: map[string]string{"n": "abc"}}
+	var c counter
+	if err := oc.ScanStruct(&c); err == nil || !strings.Contains(err.Error(), "N") {
+		t.Fatalf("invalid int should name the field, got %v", err)
+	}
+	if err := oc.ScanStruct(c); err == nil {
+		t.Fatal("non-pointer dest should fail")
+	}
+	if err := (&Outcome{Error: ErrCacheMiss}).ScanStruct(&c); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("outcome error should pass through, got %v", err)
+	}
+}
+
+func TestHSetNX(t *testing.T) {
+	rc, mr := newTestClient(t)
+	created, err := rc.HSetNX("user", "name", "bob").GetBool()
+	if err != nil || !created {
+		t.Fatalf("first HSetNX = %v, %v", created, err)
+	}
+	created, err = rc.HSetNX("user", "name", "alice").GetBool()
+	if err != nil || created {
+		t.Fatalf("second HSetNX = %v, %v", created, err)
+	}
+	if got := mr.HGet("app:test:user", "name"); got != "bob" {
+		t.Fatalf("field overwritten: %q", got)
+	}
+	if err := rc.HSetNX("user", "profile", testUser{Name: "bob"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("app:test:user", "profile"); got != `{"name":"bob","age":0}` {
+		t.Fatalf("struct value stored as %q", got)
+	}
+}
+
+func TestSetDefaultUsesDefaultTTL(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.DefaultTTL = time.Minute })
+	if err := rc.SetDefault("k", "v").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL("app:test:k"); got != time.Minute {
+		t.Fatalf("ttl = %s, want 1m", got)
+	}
+}
+
+func TestSetDefaultZeroNeverExpires(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if err := rc.SetDefault("k", "v").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.TTL("app:test:k"); got != 0 {
+		t.Fatalf("ttl = %s, want none", got)
+	}
+}
+
+func TestSetDefaultAppliesDrift(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) {
+		o.DefaultTTL = time.Minute
+		o.DriftWindow = time.Hour
+	})
+	drifted := false
+	for i := 0; i < 10 && !drifted; i++ {
+		key := fmt.Sprintf("k%d", i)
+		if err := rc.SetDefault(key, "v").Error; err != nil {
+			t.Fatal(err)
+		}
+		got := mr.TTL("app:test:" + key)
+		if got < time.Minute || got >= time.Minute+time.Hour {
+			t.Fatalf("ttl %s outside drift window", got)
+		}
+		drifted = got > time.Minute
+	}
+	if !drifted {
+		t.Fatal("DefaultTTL was never drifted")
+	}
+}
+
+func TestIndependentClientsDoNotCollide(t *testing.T) {
+	orders, mr := newTestClient(t, func(o *Options) { o.NameSpace = "orders" })
+	users, err := NewRedisClient(&Options{AppName: "app", NameSpace: "users", Addr: []string{mr.Addr()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer users.Close()
+
+	if err := orders.Set("id", "o-1", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Set("id", "u-1", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := orders.Get("id").GetString(); got != "o-1" {
+		t.Fatalf("orders id = %q", got)
+	}
+	if got, _ := users.Get("id").GetString(); got != "u-1" {
+		t.Fatalf("users id = %q", got)
+	}
+	if GetRedis() != nil {
+		t.Fatal("NewRedisClient must not touch the global client")
+	}
+}
+
+func TestPoolStatsReportsUsage(t *testing.T) {
+	rc, _ := newTestClient(t)
+	for i := 0; i < 5; i++ {
+		if err := rc.Set("k", i, 0).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := rc.PoolStats()
+	if stats.TotalConns == 0 || stats.Hits == 0 {
+		t.Fatalf("stats not reported: %+v", stats)
+	}
+}
+
+func TestAppendReturnsNewLength(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if n, err := rc.Append("log", "hello ").GetInt64(); err != nil || n != 6 {
+		t.Fatalf("first Append = %d, %v", n, err)
+	}
+	n, err := rc.Append("log", "world").GetInt64()
+	if err != nil || n != int64(len("hello world")) {
+		t.Fatalf("second Append = %d, %v", n, err)
+	}
+	if got, _ := mr.Get("app:test:log"); got != "hello world" {
+		t.Fatalf("stored %q", got)
+	}
+}
+
+func TestGetRangeAndSetRange(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if err := rc.Set("greeting", "hello world", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, err := rc.GetRange("greeting", 6, -1).GetString(); err != nil || got != "world" {
+		t.Fatalf("GetRange = %q, %v", got, err)
+	}
+	n, err := rc.SetRange("greeting", 6, "redis").GetInt64()
+	if err != nil || n != 11 {
+		t.Fatalf("SetRange = %d, %v", n, err)
+	}
+	if got, _ := mr.Get("app:test:greeting"); got != "hello redis" {
+		t.Fatalf("stored %q", got)
+	}
+}
+
+func TestMGetMapAssociatesKeys(t *testing.T) {
+	rc, _ := newTestClient(t)
+	if err := rc.MSet("a", "1", "c", testUser{Name: "c"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	outcomes, err := rc.MGetMap("a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcomes) != 3 {
+		t.Fatalf("got %d outcomes", len(outcomes))
+	}
+	if got, _ := outcomes["a"].GetString(); got != "1" {
+		t.Fatalf("a = %q", got)
+	}
+	if !outcomes["b"].IsNil() || !errors.Is(outcomes["b"].Error, ErrCacheMiss) {
+		t.Fatalf("missing key should be nil-marked, got %+v", outcomes["b"])
+	}
+	var u testUser
+	if err := outcomes["c"].Unmarshal(&u); err != nil || u.Name != "c" {
+		t.Fatalf("c = %+v, %v", u, err)
+	}
+}
+
+func TestMGetMapPipelinesSingleKeyGets(t *testing.T) {
+	rc, _ := newTestClient(t)
+	hook := newCountingHook(rc)
+	if _, err := rc.MGetMap("a", "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if hook.pipelines != 1 || hook.count("get") != 3 || hook.count("mget") != 0 {
+		t.Fatalf("%d pipelines, %d GET, %d MGET", hook.pipelines, hook.count("get"), hook.count("mget"))
+	}
+}
+
+func TestIncrByFloat(t *testing.T) {
+	rc, _ := newTestClient(t)
+	for i := 0; i < 2; i++ {
+		if err := rc.IncrByFloat("score", 1.5).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := rc.IncrByFloat("score", 0).GetFloat64(); err != nil || got != 3.0 {
+		t.Fatalf("score = %v, %v", got, err)
+	}
+}
+
+func TestUnlinkRemovesKeys(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("a", "1", 0)
+	rc.Set("b", "2", 0)
+	if n, err := rc.Unlink("a", "b", "missing").GetInt64(); err != nil || n != 2 {
+		t.Fatalf("Unlink = %d, %v", n, err)
+	}
+	if mr.Exists("app:test:a") || mr.Exists("app:test:b") {
+		t.Fatal("keys still present after Unlink")
+	}
+}
+
+func TestRenameMovesNamespacedKey(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("old", "v", 0)
+	if err := rc.Rename("old", "new").Error; err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("app:test:old") {
+		t.Fatal("old key still present")
+	}
+	if got, _ := mr.Get("app:test:new"); got != "v" {
+		t.Fatalf("new = %q", got)
+	}
+}
+
+func TestRenameNXKeepsExistingDestination(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("old", "v", 0)
+	rc.Set("new", "taken", 0)
+	renamed, err := rc.RenameNX("old", "new").GetBool()
+	if err != nil || renamed {
+		t.Fatalf("RenameNX = %v, %v", renamed, err)
+	}
+	if got, _ := mr.Get("app:test:new"); got != "taken" {
+		t.Fatalf("destination overwritten: %q", got)
+	}
+	if !mr.Exists("app:test:old") {
+		t.Fatal("source should remain when RenameNX fails")
+	}
+}
+
+func TestCrossSlotErrorIsSurfaced(t *testing.T) {
+	err := crossSlot(errors.New("CROSSSLOT Keys in request don't hash to the same slot"))
+	if !errors.Is(err, ErrCrossSlot) || !strings.Contains(err.Error(), "CROSSSLOT") {
+		t.Fatalf("crossSlot = %v", err)
+	}
+	other := errors.New("ERR no such key")
+	if crossSlot(other) != other {
+		t.Fatal("other errors must pass through unchanged")
+	}
+}
+
+func TestTypeReportsStoredType(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("s", "v", 0)
+	rc.HSet("h", "f", "v")
+	for key, want := range map[string]string{"s": "string", "h": "hash", "missing": "none"} {
+		if got, err := rc.Type(key).GetString(); err != nil || got != want {
+			t.Fatalf("Type(%s) = %q, %v; want %q", key, got, err, want)
+		}
+	}
+}
+
+func TestMissIsErrCacheMiss(t *testing.T) {
+	rc, mr := newTestClient(t)
+	oc := rc.Get("missing")
+	if !errors.Is(oc.Error, ErrCacheMiss) || !oc.IsNil() {
+		t.Fatalf("Get missing = %v", oc.Error)
+	}
+	if oc := rc.HGet("missing", "f"); !errors.Is(oc.Error, ErrCacheMiss) {
+		t.Fatalf("HGet missing = %v", oc.Error)
+	}
+
+	mr.Set("app:test:list", "v")
+	err := rc.HGet("list", "f").Error
+	if err == nil || errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("WRONGTYPE should stay a real error, got %v", err)
+	}
+	if rc.Outcome(nil, errors.New("boom")).IsNil() {
+		t.Fatal("other errors must not be reported as a miss")
+	}
+}
+
+func TestExpireAtAndPExpire(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("a", "v", 0)
+	rc.Set("p", "v", 0)
+	if ok, err := rc.ExpireAt("a", time.Now().Add(time.Hour)).GetBool(); err != nil || !ok {
+		t.Fatalf("ExpireAt = %v, %v", ok, err)
+	}
+	if ttl := mr.TTL("app:test:a"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("ExpireAt ttl = %s", ttl)
+	}
+	if ok, err := rc.PExpire("p", 1500*time.Millisecond).GetBool(); err != nil || !ok {
+		t.Fatalf("PExpire = %v, %v", ok, err)
+	}
+	if ttl := mr.TTL("app:test:p"); ttl != 1500*time.Millisecond {
+		t.Fatalf("PExpire ttl = %s", ttl)
+	}
+	if ok, _ := rc.PExpire("missing", time.Second).GetBool(); ok {
+		t.Fatal("expiring a missing key should report false")
+	}
+}
+
+func TestConditionalExpire(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("k", "v", 0)
+	if err := rc.ExpireNX("k", time.Minute).Error; err != nil {
+		t.Skipf("server does not support EXPIRE flags: %v", err)
+	}
+	if mr.TTL("app:test:k") != time.Minute {
+		t.Fatalf("ExpireNX on a key without ttl should set it, got %s", mr.TTL("app:test:k"))
+	}
+	steps := []struct {
+		name   string
+		expire func(string, time.Duration) *Outcome
+		ttl    time.Duration
+		want   bool
+		after  time.Duration
+	}{
+		{"NX with ttl", rc.ExpireNX, time.Hour, false, time.Minute},
+		{"XX with ttl", rc.ExpireXX, 2 * time.Minute, true, 2 * time.Minute},
+		{"GT smaller", rc.ExpireGT, time.Minute, false, 2 * time.Minute},
+		{"GT larger", rc.ExpireGT, 3 * time.Minute, true, 3 * time.Minute},
+		{"LT larger", rc.ExpireLT, time.Hour, false, 3 * time.Minute},
+		{"LT smaller", rc.ExpireLT, 30 * time.Second, true, 30 * time.Second},
+	}
+	for _, step := range steps {
+		ok, err := step.expire("k", step.ttl).GetBool()
+		if err != nil || ok != step.want {
+			t.Fatalf("%s = %v, %v; want %v", step.name, ok, err, step.want)
+		}
+		if ttl := mr.TTL("app:test:k"); ttl != step.after {
+			t.Fatalf("%s: ttl = %s, want %s", step.name, ttl, step.after)
+		}
+	}
+	rc.Persist("k")
+	if ok, _ := rc.ExpireXX("k", time.Minute).GetBool(); ok {
+		t.Fatal("ExpireXX on a key without ttl should report false")
+	}
+}
+func TestDumpAndRestoreUnderNewName(t *testing.T) {
+	rc, mr := newTestClient(t)
+	registerDump(t, mr)
+	rc.Set("user", testUser{Name: "bob", Age: 1}, 0)
+
+	payload, err := rc.Dump("user").GetString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Restore("copy", time.Minute, payload).Error; err != nil {
+		t.Fatal(err)
+	}
+	original, _ := mr.Get("app:test:user")
+	restored, _ := mr.Get("app:test:copy")
+	if restored != original {
+		t.Fatalf("restored %q, want %q", restored, original)
+	}
+	if mr.TTL("app:test:copy") != time.Minute {
+		t.Fatalf("restored ttl = %s", mr.TTL("app:test:copy"))
+	}
+	if err := rc.Restore("copy", 0, payload).Error; err == nil || !strings.Contains(err.Error(), "BUSYKEY") {
+		t.Fatalf("restore onto an existing key = %v", err)
+	}
+	if !rc.Dump("missing").IsNil() {
+		t.Fatal("dumping a missing key should be a miss")
+	}
+}
+
+func TestKeySeparator(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if got := rc.GetKey("user"); got != "app:test:user" {
+		t.Fatalf("default key = %q", got)
+	}
+	dashed, _ := newTestClient(t, func(o *Options) { o.KeySeparator = "-" })
+	if got := dashed.GetKey("user"); got != "app-test-user" {
+		t.Fatalf("custom key = %q", got)
+	}
+	rc.Set("user", "v", 0)
+	if !mr.Exists("app:test:user") {
+		t.Fatal("commands should use the separated key")
+	}
+}
+
+func TestSetRawStoresVerbatim(t *testing.T) {
+	rc, mr := newTestClient(t)
+	payload, _ := json.Marshal(testUser{Name: "bob"})
+	if err := rc.SetRaw("raw", payload, 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := mr.Get("app:test:raw"); got != string(payload) {
+		t.Fatalf("stored %q, want %q", got, payload)
+	}
+	// 同样的字节切片经过 Set 会被再次编码
+	rc.Set("encoded", payload, 0)
+	if got, _ := mr.Get("app:test:encoded"); got == string(payload) {
+		t.Fatal("Set should serialize the slice, SetRaw should not")
+	}
+}
+
+func TestGetWithTTL(t *testing.T) {
+	rc, _ := newTestClient(t)
+	hook := newCountingHook(rc)
+	rc.Set("k", "v", time.Minute)
+
+	value, ttl, err := rc.GetWithTTL("k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := value.GetString(); got != "v" || ttl != time.Minute {
+		t.Fatalf("GetWithTTL = %q, %s", got, ttl)
+	}
+	if hook.pipelines != 1 {
+		t.Fatalf("%d round trips, want 1", hook.pipelines)
+	}
+
+	value, ttl, err = rc.GetWithTTL("missing")
+	if err != nil || !value.IsNil() || ttl >= 0 {
+		t.Fatalf("missing key = %v, %s, %v", value.Error, ttl, err)
+	}
+}
+
+func TestSetManyAndGetManyRoundTripStructs(t *testing.T) {
+	rc, mr := newTestClient(t)
+	users := map[string]interface{}{
+		"u1": testUser{Name: "a", Age: 1},
+		"u2": testUser{Name: "b", Age: 2},
+		"u3": testUser{Name: "c", Age: 3},
+	}
+	if err := rc.SetMany(users, time.Minute).Error; err != nil {
+		t.Fatal(err)
+	}
+	for key := range users {
+		if ttl := mr.TTL("app:test:" + key); ttl != time.Minute {
+			t.Fatalf("%s ttl = %s", key, ttl)
+		}
+	}
+
+	outcomes, err := rc.GetMany("u1", "u2", "u3", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range users {
+		var got testUser
+		if err := outcomes[key].Unmarshal(&got); err != nil || got != want {
+			t.Fatalf("%s = %+v, %v", key, got, err)
+		}
+	}
+	if !outcomes["missing"].IsNil() {
+		t.Fatal("missing key should be a miss")
+	}
+}
+
+func TestSetManyReportsFailure(t *testing.T) {
+	rc, mr := newTestClient(t)
+	mr.SetError("READONLY You can't write against a read only replica.")
+	defer mr.SetError("")
+	if err := rc.SetMany(map[string]interface{}{"k": "v"}, 0).Error; err == nil || !strings.Contains(err.Error(), `"k"`) {
+		t.Fatalf("SetMany error = %v", err)
+	}
+}
+
+func TestSetKeepTTLPreservesExpiry(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("k", "v1", time.Minute)
+	mr.FastForward(10 * time.Second)
+	if err := rc.SetKeepTTL("k", testUser{Name: "v2"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("app:test:k"); ttl != 50*time.Second {
+		t.Fatalf("ttl = %s, want the remaining 50s", ttl)
+	}
+	if got, _ := mr.Get("app:test:k"); got != `{"name":"v2","age":0}` {
+		t.Fatalf("value = %q", got)
+	}
+}
+
+func TestGetDelReturnsAndRemoves(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("token", "secret", 0)
+	if got, err := rc.GetDel("token").GetString(); err != nil || got != "secret" {
+		t.Fatalf("GetDel = %q, %v", got, err)
+	}
+	if mr.Exists("app:test:token") {
+		t.Fatal("key should be deleted")
+	}
+	if !rc.GetDel("token").IsNil() {
+		t.Fatal("second GetDel should miss")
+	}
+}
+
+func TestGetDelFallsBackToScript(t *testing.T) {
+	rc, mr := newTestClient(t)
+	hook := newCountingHook(rc)
+	mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if cmd == "GETDEL" {
+			c.WriteError("ERR unknown command 'GETDEL'")
+			return true
+		}
+		return false
+	})
+	rc.Set("token", "secret", 0)
+	if got, err := rc.GetDel("token").GetString(); err != nil || got != "secret" {
+		t.Fatalf("GetDel = %q, %v", got, err)
+	}
+	if mr.Exists("app:test:token") {
+		t.Fatal("key should be deleted by the fallback")
+	}
+	if hook.count("evalsha")+hook.count("eval") == 0 {
+		t.Fatal("fallback script was not used")
+	}
+}
+
+func TestGetExResetsOrPersistsTTL(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("k", "v", time.Minute)
+	if got, err := rc.GetEx("k", time.Hour).GetString(); err != nil || got != "v" {
+		t.Fatalf("GetEx = %q, %v", got, err)
+	}
+	if ttl := mr.TTL("app:test:k"); ttl != time.Hour {
+		t.Fatalf("ttl after GetEx = %s", ttl)
+	}
+	if got, err := rc.GetEx("k", 0).GetString(); err != nil || got != "v" {
+		t.Fatalf("GetEx persist = %q, %v", got, err)
+	}
+	if ttl := mr.TTL("app:test:k"); ttl != 0 {
+		t.Fatalf("ttl after persisting = %s", ttl)
+	}
+}
+
+func TestClusterReadOnlyRouting(t *testing.T) {
+	mr := miniredis.RunT(t)
+	// 从节点连接会先发送READONLY miniredis不支持
+	if err := mr.Server().Register("READONLY", func(c *server.Peer, cmd string, args []string) {
+		c.WriteOK()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		readOnly, random      bool
+		byLatency, randomOnly bool
+	}{
+		{false, false, false, false},
+		{false, true, false, false},
+		{true, false, true, false},
+		{true, true, false, true},
+	}
+	for _, c := range cases {
+		rc, err := NewRedisClient(&Options{
+			AppName:       "app",
+			NameSpace:     "test",
+			Addr:          []string{mr.Addr(), mr.Addr()},
+			ReadOnly:      c.readOnly,
+			RouteRandomly: c.random,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		opt := rc.cluster.Options()
+		if opt.ReadOnly != c.readOnly || opt.RouteByLatency != c.byLatency || opt.RouteRandomly != c.randomOnly {
+			t.Errorf("ReadOnly=%v RouteRandomly=%v: got %+v", c.readOnly, c.random,
+				[]bool{opt.ReadOnly, opt.RouteByLatency, opt.RouteRandomly})
+		}
+		if err := rc.Set("k", "v", 0).Error; err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := rc.Get("k").GetString(); got != "v" {
+			t.Fatalf("Get through the cluster client = %q", got)
+		}
+		rc.Close()
+	}
+}
+
+func TestExistsMap(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.Set("a", "1", 0)
+	rc.Set("c", "3", 0)
+	exists, err := rc.ExistsMap("a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"a": true, "b": false, "c": true}
+	for k, v := range want {
+		if got, ok := exists[k]; !ok || got != v {
+			t.Errorf("ExistsMap[%q] = %v, %v; want %v", k, got, ok, v)
+		}
+	}
+	if len(exists) != len(want) {
+		t.Fatalf("ExistsMap returned %v", exists)
+	}
+}
+
+func TestHRandField(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.HMSet("h", map[string]interface{}{"f1": "v1", "f2": "v2", "f3": "v3"})
+	fields, err := rc.HRandField("h", 2, false).GetArray()
+	if err != nil || len(fields) != 2 {
+		t.Fatalf("HRandField = %v, %v", fields, err)
+	}
+	for _, f := range fields {
+		if f != "f1" && f != "f2" && f != "f3" {
+			t.Fatalf("field %q is not in the hash", f)
+		}
+	}
+	pairs, err := rc.HRandField("h", 3, true).GetMap()
+	if err != nil || len(pairs) != 3 {
+		t.Fatalf("HRandField withValues = %v, %v", pairs, err)
+	}
+	for f, v := range pairs {
+		if v != "v"+strings.TrimPrefix(f, "f") {
+			t.Fatalf("pair %q=%q does not match the hash", f, v)
+		}
+	}
+}
+
+func TestWithNamespaceSharesPool(t *testing.T) {
+	rc, mr := newTestClient(t)
+	orders := rc.WithNamespace("orders")
+	users := rc.WithNamespace("users")
+	orders.Set("k", "o", 0)
+	users.Set("k", "u", 0)
+	if got, _ := mr.Get("app:test:orders:k"); got != "o" {
+		t.Fatalf("orders key = %q", got)
+	}
+	if got, _ := mr.Get("app:test:users:k"); got != "u" {
+		t.Fatalf("users key = %q", got)
+	}
+	if mr.Exists("app:test:k") {
+		t.Fatal("derived clients should not write to the parent namespace")
+	}
+	if orders.single != rc.single || users.single != rc.single {
+		t.Fatal("derived clients should reuse the parent connection pool")
+	}
+	if rc.opt.NameSpace != "test" {
+		t.Fatalf("parent namespace changed to %q", rc.opt.NameSpace)
+	}
+	if got := rc.WithNamespace("orders").WithNamespace("2024").GetKey("k"); got != "app:test:orders:2024:k" {
+		t.Fatalf("nested namespace key = %q", got)
+	}
+}
+
+// exerciseCache 通过 Cache 接口调用每一个方法 RedisClient 与 MemoryCache 需要得到相同的结果
+func exerciseCache(t *testing.T, c Cache) {
+	t.Helper()
+	check := func(name string, oc *Outcome, want interface{}) {
+		t.Helper()
+		if oc.Error != nil {
+			t.Errorf("%s: %v", name, oc.Error)
+			return
+		}
+		var got interface{}
+		var err error
+		switch want.(type) {
+		case bool:
+			got, err = oc.GetBool()
+		case int64:
+			got, err = oc.GetInt64()
+		case float64:
+			got, err = oc.GetFloat64()
+		case string:
+			got, err = oc.GetString()
+		case []string:
+			var arr []string
+			arr, err = oc.GetArray()
+			got = strings.Join(arr, ",")
+			want = strings.Join(want.([]string), ",")
+		}
+		if err != nil || got != want {
+			t.Errorf("%s = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	miss := func(name string, oc *Outcome) {
+		t.Helper()
+		if !oc.IsNil() {
+			t.Errorf("%s should be a miss, got %v, %v", name, oc.Primordial, oc.Error)
+		}
+	}
+
+	if !c.Ping() {
+		t.Error("Ping failed")
+	}
+	check("Set", c.Set("s", "v", 0), "OK")
+	check("Get", c.Get("s"), "v")
+	check("GetSet", c.GetSet("s", "v2"), "v")
+	check("SetNX", c.SetNX("s", "v3", 0), false)
+	check("Expire", c.Expire("s", time.Minute), true)
+	check("Exists", c.Exists("s", "missing"), int64(1))
+	check("Del", c.Del("s"), int64(1))
+	miss("Get after Del", c.Get("s"))
+
+	check("Incr", c.Incr("n"), int64(1))
+	check("IncrBy", c.IncrBy("n", 5), int64(6))
+	check("Decr", c.Decr("n"), int64(5))
+	check("DecrBy", c.DecrBy("n", 2), int64(3))
+
+	check("MSet", c.MSet("a", "1", "b", "2"), "OK")
+	mget := c.MGet("a", "missing", "b")
+	if vals, ok := mget.Primordial.([]interface{}); mget.Error != nil || !ok || len(vals) != 3 ||
+		vals[0] != "1" || vals[1] != nil || vals[2] != "2" {
+		t.Errorf("MGet = %#v, %v", mget.Primordial, mget.Error)
+	}
+
+	check("HSet", c.HSet("h", "f", "v"), true)
+	check("HGet", c.HGet("h", "f"), "v")
+	check("HExists", c.HExists("h", "f"), true)
+	check("HIncrBy", c.HIncrBy("h", "n", 2), int64(2))
+	check("HIncrByFloat", c.HIncrByFloat("h", "x", 1.5), 1.5)
+	check("HLen", c.HLen("h"), int64(3))
+	keys, _ := c.HKeys("h").GetArray()
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "f,n,x" {
+		t.Errorf("HKeys = %v", keys)
+	}
+	if all, err := c.HGetAll("h").GetMap(); err != nil || len(all) != 3 || all["f"] != "v" {
+		t.Errorf("HGetAll = %v, %v", all, err)
+	}
+	check("HDel", c.HDel("h", "f"), int64(1))
+
+	check("RPush", c.RPush("l", "b", "c"), int64(2))
+	check("LPush", c.LPush("l", "a"), int64(3))
+	check("LLen", c.LLen("l"), int64(3))
+	check("LRange", c.LRange("l", 0, -1), []string{"a", "b", "c"})
+	check("LPop", c.LPop("l"), "a")
+	check("RPop", c.RPop("l"), "c")
+
+	check("SAdd", c.SAdd("set", "a", "b"), int64(2))
+	check("SIsMember", c.SIsMember("set", "a"), true)
+	check("SCard", c.SCard("set"), int64(2))
+	members, _ := c.SMembers("set").GetArray()
+	sort.Strings(members)
+	if strings.Join(members, ",") != "a,b" {
+		t.Errorf("SMembers = %v", members)
+	}
+	check("SRem", c.SRem("set", "a"), int64(1))
+
+	check("ZAdd", c.ZAdd("z", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}), int64(2))
+	check("ZRange", c.ZRange("z", 0, -1), []string{"a", "b"})
+	if zs, err := c.ZRangeWithScores("z", 0, 0).GetZSlice(); err != nil || len(zs) != 1 || zs[0].Score != 1 {
+		t.Errorf("ZRangeWithScores = %v, %v", zs, err)
+	}
+	check("ZRangeByScore", c.ZRangeByScore("z", &redis.ZRangeBy{Min: "(1", Max: "+inf"}), []string{"b"})
+	check("ZScore", c.ZScore("z", "b"), float64(2))
+	check("ZRank", c.ZRank("z", "b"), int64(1))
+	check("ZRem", c.ZRem("z", "a"), int64(1))
+}
+
+func TestCacheInterfaceMethods(t *testing.T) {
+	t.Run("redis", func(t *testing.T) {
+		rc, _ := newTestClient(t)
+		var c Cache = rc
+		exerciseCache(t, c)
+	})
+	t.Run("memory", func(t *testing.T) {
+		var c Cache = NewMemoryCache()
+		exerciseCache(t, c)
+	})
+}
+
+func TestRedisClientUsesPointerReceivers(t *testing.T) {
+	iface := reflect.TypeOf((*Cache)(nil)).Elem()
+	ptr := reflect.TypeOf((*RedisClient)(nil))
+	if !ptr.Implements(iface) {
+		t.Fatal("*RedisClient should implement Cache")
+	}
+	for i := 0; i < iface.NumMethod(); i++ {
+		name := iface.Method(i).Name
+		if _, ok := ptr.MethodByName(name); !ok {
+			t.Errorf("*RedisClient is missing %s", name)
+		}
+	}
+	// 值类型没有方法 说明所有方法都是指针接收者 不会复制客户端
+	if n := ptr.Elem().NumMethod(); n != 0 {
+		names := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			names = append(names, ptr.Elem().Method(i).Name)
+		}
+		t.Fatalf("value receivers found: %v", names)
+	}
+	rc, _ := newTestClient(t)
+	rc.Set("n", 10, 0)
+	var c Cache = rc
+	if n, _ := c.IncrBy("n", 5).GetInt64(); n != 15 {
+		t.Fatalf("IncrBy = %d", n)
+	}
+	if n, _ := c.DecrBy("n", 3).GetInt64(); n != 12 {
+		t.Fatalf("DecrBy = %d", n)
+	}
+	if ok, _ := c.Expire("n", time.Minute).GetBool(); !ok {
+		t.Fatal("Expire should report true")
+	}
+}
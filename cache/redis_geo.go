@@ -0,0 +1,31 @@
+package cache
+
+import "github.com/go-redis/redis/v8"
+
+// GeoAdd 添加地理位置 返回int64
+func (rc *RedisClient) GeoAdd(key string, locations ...*redis.GeoLocation) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GeoAdd(rc.ctx, hook, locations...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GeoPos 获取成员的经纬度 不存在的成员为nil 返回[]*redis.GeoPos
+func (rc *RedisClient) GeoPos(key string, members ...string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GeoPos(rc.ctx, hook, members...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GeoDist 获取两个成员间的距离 unit为m/km/mi/ft 返回float64
+func (rc *RedisClient) GeoDist(key string, member1, member2, unit string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GeoDist(rc.ctx, hook, member1, member2, unit)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// GeoRadius 获取指定范围内的成员 返回[]redis.GeoLocation
+func (rc *RedisClient) GeoRadius(key string, longitude, latitude float64, query *redis.GeoRadiusQuery) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().GeoRadius(rc.ctx, hook, longitude, latitude, query)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestGeoRadiusFindsNearbyMembers(t *testing.T) {
+	rc, _ := newTestClient(t)
+	err := rc.GeoAdd("shops",
+		&redis.GeoLocation{Name: "palermo", Longitude: 13.361389, Latitude: 38.115556},
+		&redis.GeoLocation{Name: "catania", Longitude: 15.087269, Latitude: 37.502669},
+		&redis.GeoLocation{Name: "rome", Longitude: 12.496366, Latitude: 41.902782},
+	).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dist, err := rc.GeoDist("shops", "palermo", "catania", "km").GetFloat64()
+	if err != nil || math.Abs(dist-166.27) > 1 {
+		t.Fatalf("GeoDist = %f, %v", dist, err)
+	}
+
+	locations, err := rc.GeoRadius("shops", 15, 37, &redis.GeoRadiusQuery{Radius: 200, Unit: "km"}).GetGeoLocations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(locations))
+	for _, loc := range locations {
+		names = append(names, loc.Name)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "catania" || names[1] != "palermo" {
+		t.Fatalf("members in radius = %v", names)
+	}
+
+	outcome := rc.GeoPos("shops", "rome", "missing")
+	pos, ok := outcome.Primordial.([]*redis.GeoPos)
+	if outcome.Error != nil || !ok || len(pos) != 2 || pos[0] == nil || pos[1] != nil {
+		t.Fatalf("GeoPos = %v, %v", outcome.Primordial, outcome.Error)
+	}
+}
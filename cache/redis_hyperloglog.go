@@ -0,0 +1,25 @@
+package cache
+
+// PFAdd 添加元素 基数估算值发生变化时返回1 返回int64
+func (rc *RedisClient) PFAdd(key string, els ...interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().PFAdd(rc.ctx, hook, rc.GetValues(els)...)
+	rc.invalidate(cmd.Err(), key)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// PFCount 获取基数估算值 多个key时返回并集的估算值 返回int64
+func (rc *RedisClient) PFCount(keys ...string) *Outcome {
+	hooks := rc.getHooks(keys)
+	cmd := rc.Runner().PFCount(rc.ctx, hooks...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// PFMerge 合并多个HyperLogLog到dest 返回string
+func (rc *RedisClient) PFMerge(dest string, keys ...string) *Outcome {
+	hook := rc.GetKey(dest)
+	hooks := rc.getHooks(keys)
+	cmd := rc.Runner().PFMerge(rc.ctx, hook, hooks...)
+	rc.invalidate(cmd.Err(), dest)
+	return rc.keysOutcome(append([]string{dest}, keys...), cmd.Val(), cmd)
+}
@@ -0,0 +1,31 @@
+package cache
+
+import "testing"
+
+func TestHyperLogLogMerge(t *testing.T) {
+	rc, mr := newTestClient(t)
+	for i := 0; i < 100; i++ {
+		if err := rc.PFAdd("monday", i).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 50; i < 150; i++ {
+		if err := rc.PFAdd("tuesday", i).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rc.PFMerge("week", "monday", "tuesday").Error; err != nil {
+		t.Fatal(err)
+	}
+	if !mr.Exists("app:test:week") {
+		t.Fatal("merge destination should be namespaced")
+	}
+	n, err := rc.PFCount("week").GetInt64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// HyperLogLog 标准误差约0.81% 放宽到3%
+	if n < 145 || n > 155 {
+		t.Fatalf("merged count %d not close to 150", n)
+	}
+}
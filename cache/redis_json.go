@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-redis/redis/v8"
+)
+
+// JSONSet 设置RedisJSON文档中path处的值 值固定使用json序列化 返回string
+// 需要服务器加载RedisJSON模块
+func (rc *RedisClient) JSONSet(key, path string, value interface{}) *Outcome {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return rc.Outcome(nil, err)
+	}
+	cmd := rc.do(rc.ctx, "JSON.SET", rc.GetKey(key), path, string(data))
+	rc.invalidate(cmd.Err(), key)
+	return rc.jsonOutcome(key, cmd)
+}
+
+// JSONGet 获取RedisJSON文档中path处的值 返回json字符串 可以直接 Unmarshal
+// 使用 $ 开头的path时返回的是匹配结果组成的数组
+func (rc *RedisClient) JSONGet(key, path string) *Outcome {
+	cmd := rc.do(rc.ctx, "JSON.GET", rc.GetKey(key), path)
+	return rc.jsonOutcome(key, cmd)
+}
+
+// JSONDel 删除RedisJSON文档中path处的值 返回删除的数量int64
+func (rc *RedisClient) JSONDel(key, path string) *Outcome {
+	cmd := rc.do(rc.ctx, "JSON.DEL", rc.GetKey(key), path)
+	rc.invalidate(cmd.Err(), key)
+	return rc.jsonOutcome(key, cmd)
+}
+
+// jsonOutcome 文档内容总是json 不受 Options.Codec 影响
+func (rc *RedisClient) jsonOutcome(key string, cmd *redis.Cmd) *Outcome {
+	outcome := rc.keyOutcome(key, cmd.Val(), cmd)
+	outcome.codec = JSONCodec{}
+	return outcome
+}
+
+// do 执行任意命令 参数不会自动加统一前缀
+func (rc *RedisClient) do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	if rc.flag {
+		return rc.single.Do(ctx, args...)
+	}
+	return rc.cluster.Do(ctx, args...)
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+// registerJSON miniredis没有RedisJSON模块 这里注册一个只支持 . 分隔路径的简化实现
+// 返回记录到的文档 key为完整key
+func registerJSON(t *testing.T, mr *miniredis.Miniredis) map[string]interface{} {
+	t.Helper()
+	var mu sync.Mutex
+	docs := make(map[string]interface{})
+	split := func(path string) []string {
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+		if path == "" {
+			return nil
+		}
+		return strings.Split(path, ".")
+	}
+	lookup := func(key, path string) (map[string]interface{}, string, bool) {
+		parts := split(path)
+		parent, ok := docs[key].(map[string]interface{})
+		if !ok || len(parts) == 0 {
+			return nil, "", false
+		}
+		for _, part := range parts[:len(parts)-1] {
+			if parent, ok = parent[part].(map[string]interface{}); !ok {
+				return nil, "", false
+			}
+		}
+		return parent, parts[len(parts)-1], true
+	}
+	handlers := map[string]server.Cmd{
+		"JSON.SET": func(c *server.Peer, cmd string, args []string) {
+			mu.Lock()
+			defer mu.Unlock()
+			var value interface{}
+			if err := json.Unmarshal([]byte(args[2]), &value); err != nil {
+				c.WriteError("ERR invalid json")
+				return
+			}
+			if len(split(args[1])) == 0 {
+				docs[args[0]] = value
+				c.WriteOK()
+				return
+			}
+			parent, field, ok := lookup(args[0], args[1])
+			if !ok {
+				c.WriteError("ERR path does not exist")
+				return
+			}
+			parent[field] = value
+			c.WriteOK()
+		},
+		"JSON.GET": func(c *server.Peer, cmd string, args []string) {
+			mu.Lock()
+			defer mu.Unlock()
+			doc, ok := docs[args[0]]
+			if !ok {
+				c.WriteNull()
+				return
+			}
+			value := doc
+			if len(split(args[1])) > 0 {
+				parent, field, ok := lookup(args[0], args[1])
+				if value, ok = parent[field]; !ok {
+					c.WriteError("ERR path does not exist")
+					return
+				}
+			}
+			if strings.HasPrefix(args[1], "$") {
+				value = []interface{}{value}
+			}
+			data, _ := json.Marshal(value)
+			c.WriteBulk(string(data))
+		},
+		"JSON.DEL": func(c *server.Peer, cmd string, args []string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(split(args[1])) == 0 {
+				if _, ok := docs[args[0]]; ok {
+					delete(docs, args[0])
+					c.WriteInt(1)
+					return
+				}
+				c.WriteInt(0)
+				return
+			}
+			parent, field, ok := lookup(args[0], args[1])
+			if _, exists := parent[field]; !ok || !exists {
+				c.WriteInt(0)
+				return
+			}
+			delete(parent, field)
+			c.WriteInt(1)
+		},
+	}
+	for name, handler := range handlers {
+		if err := mr.Server().Register(name, handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return docs
+}
+
+func TestJSONNestedPath(t *testing.T) {
+	rc, mr := newTestClient(t)
+	docs := registerJSON(t, mr)
+
+	type address struct {
+		City string `json:"city"`
+	}
+	doc := map[string]interface{}{"name": "bob", "address": address{City: "paris"}}
+	if err := rc.JSONSet("user", "$", doc).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := docs["app:test:user"]; !ok {
+		t.Fatal("document key should be namespaced")
+	}
+	if err := rc.JSONSet("user", "$.address.city", "rome").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var city string
+	if err := rc.JSONGet("user", ".address.city").Unmarshal(&city); err != nil || city != "rome" {
+		t.Fatalf("city = %q, %v", city, err)
+	}
+	var matches []address
+	if err := rc.JSONGet("user", "$.address").Unmarshal(&matches); err != nil || len(matches) != 1 || matches[0].City != "rome" {
+		t.Fatalf("$.address = %+v, %v", matches, err)
+	}
+
+	if n, err := rc.JSONDel("user", "$.address").GetInt64(); err != nil || n != 1 {
+		t.Fatalf("JSONDel = %d, %v", n, err)
+	}
+	if !rc.JSONGet("missing", "$").IsNil() {
+		t.Fatal("missing document should be a miss")
+	}
+}
+
+func TestJSONIgnoresCustomCodec(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.Codec = gobCodec{} })
+	registerJSON(t, mr)
+	rc.JSONSet("user", "$", testUser{Name: "bob"})
+	var u testUser
+	if err := rc.JSONGet("user", ".").Unmarshal(&u); err != nil || u.Name != "bob" {
+		t.Fatalf("decoded %+v, %v", u, err)
+	}
+}
+
+func TestJSONWithoutModule(t *testing.T) {
+	rc, _ := newTestClient(t)
+	err := rc.JSONSet("user", "$", testUser{}).Error
+	if err == nil {
+		t.Skip("server provides JSON.SET")
+	}
+	if !strings.Contains(err.Error(), "JSON.SET") && !strings.Contains(err.Error(), "json.set") {
+		t.Fatalf("error %q should name the command", err)
+	}
+}
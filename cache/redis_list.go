@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"time"
+)
+
+// LPush 从左侧插入 返回int64
+func (rc *RedisClient) LPush(key string, values ...interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LPush(rc.ctx, hook, rc.GetValues(values)...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// RPush 从右侧插入 返回int64
+func (rc *RedisClient) RPush(key string, values ...interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().RPush(rc.ctx, hook, rc.GetValues(values)...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// LPop 从左侧弹出 返回string
+func (rc *RedisClient) LPop(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LPop(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// RPop 从右侧弹出 返回string
+func (rc *RedisClient) RPop(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().RPop(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// LLen 获取list的长度 返回int64
+func (rc *RedisClient) LLen(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LLen(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// LRange 获取区间内的元素 返回[]string
+func (rc *RedisClient) LRange(key string, start, stop int64) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LRange(rc.ctx, hook, start, stop)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// BLPop 阻塞地从第一个非空list的左侧弹出 返回[]string 依次为去掉统一前缀的key和值
+// timeout为0时一直阻塞 超时返回的 IsNil 为true ctx的截止时间同样生效
+// 集群模式下所有key需要在同一个slot
+func (rc *RedisClient) BLPop(ctx context.Context, timeout time.Duration, keys ...string) *Outcome {
+	cmd := rc.Runner().BLPop(rc.context(ctx), timeout, rc.getHooks(keys)...)
+	return rc.popOutcome(cmd)
+}
+
+// BRPop 阻塞地从第一个非空list的右侧弹出 返回值与 BLPop 相同
+func (rc *RedisClient) BRPop(ctx context.Context, timeout time.Duration, keys ...string) *Outcome {
+	cmd := rc.Runner().BRPop(rc.context(ctx), timeout, rc.getHooks(keys)...)
+	return rc.popOutcome(cmd)
+}
+
+// popOutcome 去掉阻塞弹出结果中key的统一前缀
+func (rc *RedisClient) popOutcome(cmd *redis.StringSliceCmd) *Outcome {
+	val, err := cmd.Result()
+	if err != nil {
+		return rc.Outcome(nil, crossSlot(err))
+	}
+	if len(val) == 2 {
+		val[0] = strings.TrimPrefix(val[0], rc.GetKey(Null))
+	}
+	return rc.Outcome(val, nil)
+}
+
+// LInsert 在pivot之前或之后插入 op为BEFORE或AFTER 值会自动序列化 返回插入后的长度int64 pivot不存在时返回-1
+func (rc *RedisClient) LInsert(key, op, pivot string, value interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LInsert(rc.ctx, hook, op, pivot, rc.GetValue(value))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// LSet 设置index处的元素 值会自动序列化 返回string
+func (rc *RedisClient) LSet(key string, index int64, value interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LSet(rc.ctx, hook, index, rc.GetValue(value))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// LRem 删除list中count个等于value的元素 值会自动序列化 count为0时删除全部 返回删除的数量int64
+func (rc *RedisClient) LRem(key string, count int64, value interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().LRem(rc.ctx, hook, count, rc.GetValue(value))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// LMove 原子地从src的srcPos端弹出并插入dst的dstPos端 位置为LEFT或RIGHT 需要redis 6.2及以上
+// 集群模式下src和dst需要在同一个slot 可以使用hash tag src为空时 IsNil 为true 返回string
+func (rc *RedisClient) LMove(src, dst, srcPos, dstPos string) *Outcome {
+	cmd := rc.Runner().LMove(rc.ctx, rc.GetKey(src), rc.GetKey(dst), srcPos, dstPos)
+	return rc.Outcome(cmd.Val(), rc.wrapKeysError(cmd.Name(), []string{src, dst}, crossSlot(cmd.Err())))
+}
+
+// RPopLPush 原子地从src右侧弹出并插入dst左侧 与 LMove 相同需要在同一个slot 返回string
+func (rc *RedisClient) RPopLPush(src, dst string) *Outcome {
+	cmd := rc.Runner().RPopLPush(rc.ctx, rc.GetKey(src), rc.GetKey(dst))
+	return rc.Outcome(cmd.Val(), rc.wrapKeysError(cmd.Name(), []string{src, dst}, crossSlot(cmd.Err())))
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListPushAndRange(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if n, err := rc.RPush("users", testUser{Name: "a"}, testUser{Name: "b"}).GetInt64(); err != nil || n != 2 {
+		t.Fatalf("rpush: %d, %v", n, err)
+	}
+	if n, _ := rc.LPush("users", testUser{Name: "c"}).GetInt64(); n != 3 {
+		t.Fatalf("lpush len %d", n)
+	}
+	if !mr.Exists("app:test:users") {
+		t.Fatal("list key should be namespaced")
+	}
+	arr, err := rc.LRange("users", 0, -1).GetArray()
+	if err != nil || len(arr) != 3 {
+		t.Fatalf("lrange: %v, %v", arr, err)
+	}
+	names := make([]string, 0, len(arr))
+	for _, item := range arr {
+		var u testUser
+		if err := json.Unmarshal([]byte(item), &u); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, u.Name)
+	}
+	if names[0] != "c" || names[1] != "a" || names[2] != "b" {
+		t.Fatalf("order %v", names)
+	}
+	if n, _ := rc.LLen("users").GetInt64(); n != 3 {
+		t.Fatalf("llen %d", n)
+	}
+	var u testUser
+	if err := rc.LPop("users").Unmarshal(&u); err != nil || u.Name != "c" {
+		t.Fatalf("lpop %+v, %v", u, err)
+	}
+	if err := rc.RPop("users").Unmarshal(&u); err != nil || u.Name != "b" {
+		t.Fatalf("rpop %+v, %v", u, err)
+	}
+	if !rc.LPop("empty").IsNil() {
+		t.Fatal("pop from a missing list should be a miss")
+	}
+}
+
+func TestBLPopUnblocksOnPush(t *testing.T) {
+	rc, _ := newTestClient(t)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		rc.RPush("jobs", "job-1")
+	}()
+	arr, err := rc.BLPop(context.Background(), 2*time.Second, "other", "jobs").GetStringSlice()
+	if err != nil || len(arr) != 2 || arr[0] != "jobs" || arr[1] != "job-1" {
+		t.Fatalf("BLPop = %v, %v", arr, err)
+	}
+}
+
+func TestBRPopPopsFromTail(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.RPush("jobs", "first", "last")
+	arr, err := rc.BRPop(context.Background(), time.Second, "jobs").GetStringSlice()
+	if err != nil || arr[1] != "last" {
+		t.Fatalf("BRPop = %v, %v", arr, err)
+	}
+}
+
+func TestBLPopTimeoutIsMiss(t *testing.T) {
+	rc, _ := newTestClient(t)
+	start := time.Now()
+	oc := rc.BLPop(context.Background(), time.Second, "empty")
+	if !oc.IsNil() {
+		t.Fatalf("BLPop on an empty list = %v", oc.Error)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("returned after %s, before the timeout", elapsed)
+	}
+}
+
+func TestBLPopRespectsContext(t *testing.T) {
+	rc, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	oc := rc.BLPop(ctx, 10*time.Second, "empty")
+	if oc.Error == nil || oc.IsNil() {
+		t.Fatalf("BLPop with an expiring context = %v", oc.Error)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("context deadline ignored, took %s", elapsed)
+	}
+}
+
+func TestListInPlaceEdits(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.RPush("l", "a", "c", "a")
+	if n, err := rc.LInsert("l", "BEFORE", "c", "b").GetInt64(); err != nil || n != 4 {
+		t.Fatalf("linsert before: %d, %v", n, err)
+	}
+	if n, _ := rc.LInsert("l", "AFTER", "c", "d").GetInt64(); n != 5 {
+		t.Fatalf("linsert after: %d", n)
+	}
+	if err := rc.LSet("l", 0, "x").Error; err != nil {
+		t.Fatal(err)
+	}
+	if arr, _ := rc.LRange("l", 0, -1).GetArray(); strings.Join(arr, ",") != "x,b,c,d,a" {
+		t.Fatalf("list after edits %v", arr)
+	}
+	if n, _ := rc.LRem("l", 0, "a").GetInt64(); n != 1 {
+		t.Fatalf("lrem removed %d", n)
+	}
+	if err := rc.LSet("l", 10, "y").Error; err == nil {
+		t.Fatal("lset out of range should fail")
+	}
+
+	// 结构体值与 RPush 使用相同的序列化 可以直接按值删除
+	rc.RPush("users", testUser{Name: "a"}, testUser{Name: "b"}, testUser{Name: "a"})
+	if n, _ := rc.LRem("users", 0, testUser{Name: "a"}).GetInt64(); n != 2 {
+		t.Fatalf("lrem struct removed %d", n)
+	}
+	if n, _ := rc.LLen("users").GetInt64(); n != 1 {
+		t.Fatalf("llen after lrem %d", n)
+	}
+}
+
+func TestLMoveAndRPopLPush(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.RPush("src", "a", "b", "c")
+	rc.RPush("dst", "x")
+	if v, err := rc.LMove("src", "dst", "LEFT", "RIGHT").GetString(); err != nil || v != "a" {
+		t.Fatalf("lmove: %q, %v", v, err)
+	}
+	if v, _ := rc.RPopLPush("src", "dst").GetString(); v != "c" {
+		t.Fatalf("rpoplpush: %q", v)
+	}
+	if arr, _ := rc.LRange("src", 0, -1).GetArray(); strings.Join(arr, ",") != "b" {
+		t.Fatalf("src after move %v", arr)
+	}
+	if arr, _ := rc.LRange("dst", 0, -1).GetArray(); strings.Join(arr, ",") != "c,x,a" {
+		t.Fatalf("dst after move %v", arr)
+	}
+	if !rc.RPopLPush("empty", "dst").IsNil() {
+		t.Fatal("moving from a missing list should be a miss")
+	}
+}
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ScanKeys 使用SCAN遍历当前命名空间下匹配pattern的key 返回去掉命名空间前缀的key
+// 集群模式下会遍历所有master节点
+func (rc *RedisClient) ScanKeys(pattern string, count int64) ([]string, error) {
+	prefix := rc.GetKey(Null)
+	hooks, err := rc.scan(escapePattern(prefix)+pattern, count)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(hooks))
+	for i := range hooks {
+		keys = append(keys, strings.TrimPrefix(hooks[i], prefix))
+	}
+	return keys, nil
+}
+
+// scan 遍历所有节点 返回匹配的完整key
+func (rc *RedisClient) scan(match string, count int64) ([]string, error) {
+	if rc.flag {
+		return scanNode(rc.ctx, rc.single, match, count)
+	}
+	var mu sync.Mutex
+	hooks := make([]string, 0)
+	err := rc.cluster.ForEachMaster(rc.ctx, func(ctx context.Context, client *redis.Client) error {
+		keys, err := scanNode(ctx, client, match, count)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		hooks = append(hooks, keys...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// scanNode 在单个节点上遍历完整个游标 SCAN可能返回重复的key 这里去重
+func scanNode(ctx context.Context, client *redis.Client, match string, count int64) ([]string, error) {
+	seen := make(map[string]struct{})
+	hooks := make([]string, 0)
+	iter := client.Scan(ctx, 0, match, count).Iterator()
+	for iter.Next(ctx) {
+		if _, ok := seen[iter.Val()]; ok {
+			continue
+		}
+		seen[iter.Val()] = struct{}{}
+		hooks = append(hooks, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// escapePattern 转义glob特殊字符 防止命名空间中的字符被当作通配符
+func escapePattern(raw string) string {
+	var sb strings.Builder
+	for _, r := range raw {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// unlinkBatchSize 每个管道批次删除的key数量
+const unlinkBatchSize = 500
+
+// DelByPattern 使用SCAN查找当前命名空间下匹配pattern的key 并分批UNLINK 返回删除的数量
+// pattern中的*同样会匹配 WithNamespace 创建的子命名空间中的key
+func (rc *RedisClient) DelByPattern(pattern string) (int64, error) {
+	hooks, err := rc.scan(escapePattern(rc.GetKey(Null))+pattern, unlinkBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := rc.unlinkHooks(hooks)
+	rc.invalidateHooks(err, hooks...)
+	return deleted, err
+}
+
+// FlushNamespace 删除当前命名空间下的所有key 返回删除的数量 不会影响上级和同级的命名空间
+// WithNamespace 创建的子命名空间位于当前命名空间之下 其中的key会一起删除
+// 共享的redis中不要使用FLUSHDB
+func (rc *RedisClient) FlushNamespace() (int64, error) {
+	return rc.DelByPattern("*")
+}
+
+// unlinkHooks 分批通过管道UNLINK完整key 每条命令只删一个key 避免集群模式下跨slot
+func (rc *RedisClient) unlinkHooks(hooks []string) (int64, error) {
+	var deleted int64
+	for start := 0; start < len(hooks); start += unlinkBatchSize {
+		end := start + unlinkBatchSize
+		if end > len(hooks) {
+			end = len(hooks)
+		}
+		pipe := rc.Runner().Pipeline()
+		cmds := make([]*redis.IntCmd, 0, end-start)
+		for _, hook := range hooks[start:end] {
+			cmds = append(cmds, pipe.Unlink(rc.ctx, hook))
+		}
+		if _, err := pipe.Exec(rc.ctx); err != nil {
+			return deleted, err
+		}
+		for _, cmd := range cmds {
+			deleted += cmd.Val()
+		}
+	}
+	return deleted, nil
+}
+
+// HScan 使用HSCAN分批遍历hash 避免大hash一次性 HGetAll 阻塞服务器
+func (rc *RedisClient) HScan(key, pattern string, count int64) (map[string]string, error) {
+	hook := rc.GetKey(key)
+	fields := make(map[string]string)
+	iter := rc.Runner().HScan(rc.ctx, hook, 0, pattern, count).Iterator()
+	for iter.Next(rc.ctx) {
+		field := iter.Val()
+		if !iter.Next(rc.ctx) {
+			break
+		}
+		fields[field] = iter.Val()
+	}
+	if err := iter.Err(); err != nil {
+		return nil, rc.WrapError("hscan", key, err)
+	}
+	return fields, nil
+}
+
+// SScan 使用SSCAN分批遍历集合 返回的成员已去重
+func (rc *RedisClient) SScan(key, pattern string, count int64) ([]string, error) {
+	hook := rc.GetKey(key)
+	seen := make(map[string]struct{})
+	members := make([]string, 0)
+	iter := rc.Runner().SScan(rc.ctx, hook, 0, pattern, count).Iterator()
+	for iter.Next(rc.ctx) {
+		if _, ok := seen[iter.Val()]; ok {
+			continue
+		}
+		seen[iter.Val()] = struct{}{}
+		members = append(members, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, rc.WrapError("sscan", key, err)
+	}
+	return members, nil
+}
+
+// ZScan 使用ZSCAN分批遍历有序集合 返回成员和分数
+func (rc *RedisClient) ZScan(key, pattern string, count int64) ([]redis.Z, error) {
+	hook := rc.GetKey(key)
+	seen := make(map[string]struct{})
+	members := make([]redis.Z, 0)
+	iter := rc.Runner().ZScan(rc.ctx, hook, 0, pattern, count).Iterator()
+	for iter.Next(rc.ctx) {
+		member := iter.Val()
+		if !iter.Next(rc.ctx) {
+			break
+		}
+		score, err := strconv.ParseFloat(iter.Val(), 64)
+		if err != nil {
+			return nil, rc.WrapError("zscan", key, err)
+		}
+		if _, ok := seen[member]; ok {
+			continue
+		}
+		seen[member] = struct{}{}
+		members = append(members, redis.Z{Score: score, Member: member})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, rc.WrapError("zscan", key, err)
+	}
+	return members, nil
+}
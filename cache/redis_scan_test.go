@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alicebob/miniredis/v2/server"
+)
+
+func TestScanKeysOnlyNamespace(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("user:1", "a", 0)
+	rc.Set("user:2", "b", 0)
+	rc.Set("order:1", "c", 0)
+	mr.Set("app:other:user:3", "d")
+	mr.Set("user:4", "e")
+	keys, err := rc.ScanKeys("user:*", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:2" {
+		t.Fatalf("keys %v", keys)
+	}
+	all, _ := rc.ScanKeys("*", 1)
+	if len(all) != 3 {
+		t.Fatalf("all keys %v", all)
+	}
+}
+
+func TestScanKeysEscapesNamespace(t *testing.T) {
+	rc, mr := newTestClient(t, func(opt *Options) { opt.NameSpace = "t*" })
+	rc.Set("k", "v", 0)
+	mr.Set("app:tx:k", "other")
+	keys, err := rc.ScanKeys("*", 10)
+	if err != nil || len(keys) != 1 || keys[0] != "k" {
+		t.Fatalf("keys %v, %v", keys, err)
+	}
+}
+
+func TestDelByPatternRemovesOnlyMatches(t *testing.T) {
+	rc, mr := newTestClient(t)
+	hook := newCountingHook(rc)
+	for i := 0; i < 1200; i++ {
+		mr.Set(fmt.Sprintf("app:test:session:%d", i), "v")
+	}
+	mr.Set("app:test:user:1", "keep")
+	mr.Set("app:other:session:1", "keep")
+
+	n, err := rc.DelByPattern("session:*")
+	if err != nil || n != 1200 {
+		t.Fatalf("DelByPattern = %d, %v", n, err)
+	}
+	if hook.count("keys") != 0 {
+		t.Fatal("KEYS must not be used")
+	}
+	if hook.pipelines != 3 {
+		t.Fatalf("%d unlink batches, want 3", hook.pipelines)
+	}
+	if !mr.Exists("app:test:user:1") || !mr.Exists("app:other:session:1") {
+		t.Fatal("non-matching keys were deleted")
+	}
+	if keys, _ := rc.ScanKeys("session:*", 100); len(keys) != 0 {
+		t.Fatalf("left %d session keys", len(keys))
+	}
+}
+
+// pageScans miniredis的HSCAN/SSCAN/ZSCAN忽略COUNT一次返回全部 这里按COUNT分页返回 并统计游标往返次数
+func pageScans(mr *miniredis.Miniredis) *int32 {
+	var calls int32
+	mr.Server().SetPreHook(func(c *server.Peer, cmd string, args ...string) bool {
+		if cmd != "HSCAN" && cmd != "SSCAN" && cmd != "ZSCAN" {
+			return false
+		}
+		key := args[0]
+		cursor, _ := strconv.Atoi(args[1])
+		count := 10
+		for i := 2; i+1 < len(args); i += 2 {
+			switch strings.ToUpper(args[i]) {
+			case "COUNT":
+				count, _ = strconv.Atoi(args[i+1])
+			case "MATCH":
+				return false
+			}
+		}
+		var items []string
+		switch cmd {
+		case "HSCAN":
+			fields, _ := mr.HKeys(key)
+			for _, field := range fields {
+				items = append(items, field, mr.HGet(key, field))
+			}
+			count *= 2
+		case "SSCAN":
+			items, _ = mr.Members(key)
+		case "ZSCAN":
+			members, _ := mr.ZMembers(key)
+			for _, member := range members {
+				score, _ := mr.ZScore(key, member)
+				items = append(items, member, strconv.FormatFloat(score, 'f', -1, 64))
+			}
+			count *= 2
+		}
+		end := cursor + count
+		next := strconv.Itoa(end)
+		if end >= len(items) {
+			end, next = len(items), "0"
+		}
+		atomic.AddInt32(&calls, 1)
+		c.WriteLen(2)
+		c.WriteBulk(next)
+		c.WriteStrings(items[cursor:end])
+		return true
+	})
+	return &calls
+}
+
+func TestHScanIteratesAllFields(t *testing.T) {
+	rc, mr := newTestClient(t)
+	calls := pageScans(mr)
+	for i := 0; i < 250; i++ {
+		mr.HSet("app:test:big", fmt.Sprintf("f%d", i), fmt.Sprint(i))
+	}
+	fields, err := rc.HScan("big", "", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 250 || fields["f123"] != "123" {
+		t.Fatalf("got %d fields, f123 = %q", len(fields), fields["f123"])
+	}
+	if n := atomic.LoadInt32(calls); n < 2 {
+		t.Fatalf("%d HSCAN calls, want several cursor steps", n)
+	}
+}
+
+func TestSScanIteratesAllMembers(t *testing.T) {
+	rc, mr := newTestClient(t)
+	calls := pageScans(mr)
+	for i := 0; i < 250; i++ {
+		mr.SetAdd("app:test:tags", fmt.Sprintf("m%d", i))
+	}
+	members, err := rc.SScan("tags", "", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(members)
+	if len(members) != 250 || members[0] != "m0" {
+		t.Fatalf("got %d members", len(members))
+	}
+	if n := atomic.LoadInt32(calls); n < 2 {
+		t.Fatalf("%d SSCAN calls, want several cursor steps", n)
+	}
+}
+
+func TestZScanIteratesAllPairs(t *testing.T) {
+	rc, mr := newTestClient(t)
+	calls := pageScans(mr)
+	for i := 0; i < 250; i++ {
+		mr.ZAdd("app:test:board", float64(i)+0.5, fmt.Sprintf("p%d", i))
+	}
+	members, err := rc.ZScan("board", "", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 250 {
+		t.Fatalf("got %d members", len(members))
+	}
+	for _, z := range members {
+		var i int
+		fmt.Sscanf(z.Member.(string), "p%d", &i)
+		if z.Score != float64(i)+0.5 {
+			t.Fatalf("%v has score %v", z.Member, z.Score)
+		}
+	}
+	if n := atomic.LoadInt32(calls); n < 2 {
+		t.Fatalf("%d ZSCAN calls, want several cursor steps", n)
+	}
+}
+
+func TestFlushNamespaceKeepsOtherNamespaces(t *testing.T) {
+	rc, mr := newTestClient(t)
+	rc.Set("a", "1", 0)
+	rc.Set("b", "2", 0)
+	mr.Set("app:other:a", "keep")
+	if n, err := rc.FlushNamespace(); err != nil || n != 2 {
+		t.Fatalf("FlushNamespace = %d, %v", n, err)
+	}
+	if !mr.Exists("app:other:a") {
+		t.Fatal("other namespace was flushed")
+	}
+}
+
+func TestFlushNamespaceIncludesChildNamespaces(t *testing.T) {
+	rc, mr := newTestClient(t)
+	child, sibling := rc.WithNamespace("child"), rc.WithNamespace("sibling")
+	rc.Set("a", "1", 0)
+	child.Set("a", "2", 0)
+	sibling.Set("a", "3", 0)
+	if n, err := child.FlushNamespace(); err != nil || n != 1 {
+		t.Fatalf("child FlushNamespace = %d, %v", n, err)
+	}
+	if !mr.Exists(rc.GetKey("a")) || !mr.Exists(sibling.GetKey("a")) {
+		t.Fatal("flushing a child namespace touched its parent or sibling")
+	}
+	child.Set("a", "2", 0)
+	if n, err := rc.FlushNamespace(); err != nil || n != 3 {
+		t.Fatalf("parent FlushNamespace = %d, %v", n, err)
+	}
+	if len(mr.Keys()) != 0 {
+		t.Fatalf("keys left after flushing the parent: %v", mr.Keys())
+	}
+}
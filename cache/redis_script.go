@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+	"sync"
+)
+
+// scripts 缓存已计算SHA的脚本 SHA只与脚本内容有关 所有客户端共用
+var scripts sync.Map
+
+// Eval 执行lua脚本 keys会自动加上统一前缀 返回脚本的原始结果
+// 优先使用EVALSHA 服务器未缓存脚本(NOSCRIPT)时回退到EVAL
+// 脚本可能写入keys 执行后使这些key的本地缓存失效
+func (rc *RedisClient) Eval(script string, keys []string, args ...interface{}) *Outcome {
+	cmd := loadScript(script).Run(rc.ctx, rc.Runner(), rc.getHooks(keys), args...)
+	rc.invalidate(cmd.Err(), keys...)
+	return rc.keysOutcome(keys, cmd.Val(), cmd)
+}
+
+// loadScript 获取缓存的脚本
+func loadScript(script string) *redis.Script {
+	if value, ok := scripts.Load(script); ok {
+		return value.(*redis.Script)
+	}
+	value, _ := scripts.LoadOrStore(script, redis.NewScript(script))
+	return value.(*redis.Script)
+}
@@ -0,0 +1,32 @@
+package cache
+
+import "testing"
+
+const compareAndIncr = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("INCR", KEYS[1])
+end
+return -1
+`
+
+func TestEvalFallsBackThenUsesSHA(t *testing.T) {
+	rc, mr := newTestClient(t)
+	hook := newCountingHook(rc)
+	mr.Set("app:test:n", "1")
+
+	if n, err := rc.Eval(compareAndIncr, []string{"n"}, "1").GetInt64(); err != nil || n != 2 {
+		t.Fatalf("first eval: %d, %v", n, err)
+	}
+	if hook.count("evalsha") != 1 || hook.count("eval") != 1 {
+		t.Fatalf("first call should try EVALSHA then EVAL, got evalsha=%d eval=%d", hook.count("evalsha"), hook.count("eval"))
+	}
+	if n, err := rc.Eval(compareAndIncr, []string{"n"}, "2").GetInt64(); err != nil || n != 3 {
+		t.Fatalf("second eval: %d, %v", n, err)
+	}
+	if hook.count("evalsha") != 2 || hook.count("eval") != 1 {
+		t.Fatalf("second call should only use EVALSHA, got evalsha=%d eval=%d", hook.count("evalsha"), hook.count("eval"))
+	}
+	if n, _ := rc.Eval(compareAndIncr, []string{"n"}, "9").GetInt64(); n != -1 {
+		t.Fatalf("mismatched compare returned %d", n)
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+// SAdd 添加集合成员 返回int64
+func (rc *RedisClient) SAdd(key string, members ...interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SAdd(rc.ctx, hook, rc.GetValues(members)...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SRem 删除集合成员 返回int64
+func (rc *RedisClient) SRem(key string, members ...interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SRem(rc.ctx, hook, rc.GetValues(members)...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SMembers 获取集合所有成员 返回[]string
+func (rc *RedisClient) SMembers(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SMembers(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SIsMember 判断是否为集合成员 返回bool
+func (rc *RedisClient) SIsMember(key string, member interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SIsMember(rc.ctx, hook, rc.GetValue(member))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SCard 获取集合的成员数 返回int64
+func (rc *RedisClient) SCard(key string) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SCard(rc.ctx, hook)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SRandMember 随机获取count个成员 不会删除 count为负数时可能重复 返回[]string
+func (rc *RedisClient) SRandMember(key string, count int) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SRandMemberN(rc.ctx, hook, int64(count))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// SPop 随机弹出count个成员 返回[]string
+func (rc *RedisClient) SPop(key string, count int) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().SPopN(rc.ctx, hook, int64(count))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSetMembership(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if n, err := rc.SAdd("tags", "a", "b", "a").GetInt64(); err != nil || n != 2 {
+		t.Fatalf("sadd: %d, %v", n, err)
+	}
+	if n, _ := rc.SAdd("tags", "b").GetInt64(); n != 0 {
+		t.Fatalf("duplicate member added %d", n)
+	}
+	if !mr.Exists("app:test:tags") {
+		t.Fatal("set key should be namespaced")
+	}
+	if ok, _ := rc.SIsMember("tags", "a").GetBool(); !ok {
+		t.Fatal("a should be a member")
+	}
+	if ok, _ := rc.SIsMember("tags", "z").GetBool(); ok {
+		t.Fatal("z should not be a member")
+	}
+	if n, _ := rc.SCard("tags").GetInt64(); n != 2 {
+		t.Fatalf("scard %d", n)
+	}
+	members, err := rc.SMembers("tags").GetArray()
+	sort.Strings(members)
+	if err != nil || len(members) != 2 || members[0] != "a" || members[1] != "b" {
+		t.Fatalf("smembers %v, %v", members, err)
+	}
+	if n, _ := rc.SRem("tags", "a").GetInt64(); n != 1 {
+		t.Fatalf("srem %d", n)
+	}
+	if n, _ := rc.SCard("tags").GetInt64(); n != 1 {
+		t.Fatalf("scard after srem %d", n)
+	}
+}
+
+func TestSMembersDecodesStructArray(t *testing.T) {
+	rc, _ := newTestClient(t)
+	want := []testUser{{Name: "a", Age: 1}, {Name: "b", Age: 2}, {Name: "c", Age: 3}}
+	for _, u := range want {
+		if err := rc.SAdd("users", u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []testUser
+	if err := rc.SMembers("users").GetStructArray(&got); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Name < got[j].Name })
+	if len(got) != 3 || got[0] != want[0] || got[2] != want[2] {
+		t.Fatalf("decoded %+v", got)
+	}
+	var notSlice testUser
+	if err := rc.SMembers("users").GetStructArray(&notSlice); err != ErrTypeMatch {
+		t.Fatalf("non-slice dest = %v", err)
+	}
+}
+
+func TestSRandMemberAndSPop(t *testing.T) {
+	rc, _ := newTestClient(t)
+	rc.SAdd("s", "a", "b", "c", "d")
+	sample, err := rc.SRandMember("s", 2).GetArray()
+	if err != nil || len(sample) != 2 {
+		t.Fatalf("srandmember %v, %v", sample, err)
+	}
+	if n, _ := rc.SCard("s").GetInt64(); n != 4 {
+		t.Fatalf("srandmember changed cardinality to %d", n)
+	}
+	popped, err := rc.SPop("s", 3).GetArray()
+	if err != nil || len(popped) != 3 {
+		t.Fatalf("spop %v, %v", popped, err)
+	}
+	if n, _ := rc.SCard("s").GetInt64(); n != 1 {
+		t.Fatalf("scard after spop %d", n)
+	}
+	for _, m := range popped {
+		if ok, _ := rc.SIsMember("s", m).GetBool(); ok {
+			t.Fatalf("popped member %q is still in the set", m)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"github.com/go-redis/redis/v8"
+	"strings"
+)
+
+// XAdd 写入一条消息 values会自动序列化 返回消息ID string
+func (rc *RedisClient) XAdd(stream string, values map[string]interface{}) *Outcome {
+	hook := rc.GetKey(stream)
+	fields := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		fields[field] = rc.GetValue(value)
+	}
+	cmd := rc.Runner().XAdd(rc.ctx, &redis.XAddArgs{
+		Stream: hook,
+		Values: fields,
+	})
+	return rc.keyOutcome(stream, cmd.Val(), cmd)
+}
+
+// XGroupCreate 创建消费者组 stream不存在时自动创建 返回string
+func (rc *RedisClient) XGroupCreate(stream, group, start string) *Outcome {
+	hook := rc.GetKey(stream)
+	cmd := rc.Runner().XGroupCreateMkStream(rc.ctx, hook, group, start)
+	return rc.keyOutcome(stream, cmd.Val(), cmd)
+}
+
+// XRead 读取消息 args.Streams 为 stream 列表加上对应的起始ID 返回[]redis.XStream
+func (rc *RedisClient) XRead(args *redis.XReadArgs) *Outcome {
+	a := *args
+	a.Streams = rc.streamHooks(args.Streams)
+	cmd := rc.Runner().XRead(rc.ctx, &a)
+	return rc.keysOutcome(args.Streams[:len(args.Streams)/2], rc.trimStreams(cmd.Val()), cmd)
+}
+
+// XReadGroup 以消费者组读取消息 args.Streams 格式同 XRead 返回[]redis.XStream
+func (rc *RedisClient) XReadGroup(args *redis.XReadGroupArgs) *Outcome {
+	a := *args
+	a.Streams = rc.streamHooks(args.Streams)
+	cmd := rc.Runner().XReadGroup(rc.ctx, &a)
+	return rc.keysOutcome(args.Streams[:len(args.Streams)/2], rc.trimStreams(cmd.Val()), cmd)
+}
+
+// XAck 确认消息 返回int64
+func (rc *RedisClient) XAck(stream, group string, ids ...string) *Outcome {
+	hook := rc.GetKey(stream)
+	cmd := rc.Runner().XAck(rc.ctx, hook, group, ids...)
+	return rc.keyOutcome(stream, cmd.Val(), cmd)
+}
+
+// streamHooks Streams 的前半部分是stream 后半部分是ID 只给stream加前缀
+func (rc *RedisClient) streamHooks(streams []string) []string {
+	hooks := make([]string, 0, len(streams))
+	half := len(streams) / 2
+	hooks = append(hooks, rc.getHooks(streams[:half])...)
+	return append(hooks, streams[half:]...)
+}
+
+// trimStreams 去掉返回结果中stream的统一前缀
+func (rc *RedisClient) trimStreams(streams []redis.XStream) []redis.XStream {
+	prefix := rc.GetKey(Null)
+	for i := range streams {
+		streams[i].Stream = strings.TrimPrefix(streams[i].Stream, prefix)
+	}
+	return streams
+}
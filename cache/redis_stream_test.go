@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestStreamErrorsNameTheStream(t *testing.T) {
+	rc, mr := newTestClient(t)
+	mr.Set(rc.GetKey("orders"), "not a stream")
+	for name, err := range map[string]error{
+		"xadd":   rc.XAdd("orders", map[string]interface{}{"id": 1}).Error,
+		"xgroup": rc.XGroupCreate("orders", "billing", "0").Error,
+		"xack":   rc.XAck("orders", "billing", "0-1").Error,
+	} {
+		if err == nil || !strings.Contains(err.Error(), name) || !strings.Contains(err.Error(), `"orders"`) {
+			t.Fatalf("%s error = %v", name, err)
+		}
+	}
+}
+
+func TestStreamConsumerGroupReadAndAck(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if err := rc.XGroupCreate("orders", "billing", "0").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"o-1", "o-2", "o-3"} {
+		if err := rc.XAdd("orders", map[string]interface{}{"id": id, "user": testUser{Name: "bob"}}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !mr.Exists("app:test:orders") {
+		t.Fatal("stream key should be namespaced")
+	}
+
+	streams, err := rc.XReadGroup(&redis.XReadGroupArgs{
+		Group:    "billing",
+		Consumer: "worker",
+		Streams:  []string{"orders", ">"},
+		Count:    10,
+	}).GetXStreams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streams) != 1 || streams[0].Stream != "orders" || len(streams[0].Messages) != 3 {
+		t.Fatalf("read %+v", streams)
+	}
+	first := streams[0].Messages[0]
+	if first.Values["id"] != "o-1" || first.Values["user"] != `{"name":"bob","age":0}` {
+		t.Fatalf("values %+v", first.Values)
+	}
+
+	ids := make([]string, 0, 3)
+	for _, msg := range streams[0].Messages {
+		ids = append(ids, msg.ID)
+	}
+	if n, err := rc.XAck("orders", "billing", ids[:2]...).GetInt64(); err != nil || n != 2 {
+		t.Fatalf("XAck = %d, %v", n, err)
+	}
+	pending, err := rc.Runner().XPending(rc.ctx, "app:test:orders", "billing").Result()
+	if err != nil || pending.Count != 1 || pending.Lower != ids[2] {
+		t.Fatalf("pending after ack = %+v, %v", pending, err)
+	}
+}
+
+func TestStreamXReadFromStart(t *testing.T) {
+	rc, _ := newTestClient(t)
+	if err := rc.XAdd("events", map[string]interface{}{"n": 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	streams, err := rc.XRead(&redis.XReadArgs{Streams: []string{"events", "0"}, Count: 1}).GetXStreams()
+	if err != nil || len(streams) != 1 || streams[0].Stream != "events" || streams[0].Messages[0].Values["n"] != "1" {
+		t.Fatalf("XRead = %+v, %v", streams, err)
+	}
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v8"
+)
+
+// ZAdd 添加有序集合成员 返回int64
+func (rc *RedisClient) ZAdd(key string, members ...redis.Z) *Outcome {
+	hook := rc.GetKey(key)
+	zs := make([]*redis.Z, 0, len(members))
+	for i := range members {
+		zs = append(zs, &redis.Z{
+			Score:  members[i].Score,
+			Member: rc.GetValue(members[i].Member),
+		})
+	}
+	cmd := rc.Runner().ZAdd(rc.ctx, hook, zs...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ZRem 删除有序集合成员 返回int64
+func (rc *RedisClient) ZRem(key string, members ...interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ZRem(rc.ctx, hook, rc.GetValues(members)...)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ZRange 按排名获取成员 返回[]string
+func (rc *RedisClient) ZRange(key string, start, stop int64) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ZRange(rc.ctx, hook, start, stop)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ZRangeWithScores 按排名获取成员和分数 返回[]redis.Z
+func (rc *RedisClient) ZRangeWithScores(key string, start, stop int64) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ZRangeWithScores(rc.ctx, hook, start, stop)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ZRangeByScore 按分数获取成员 返回[]string
+func (rc *RedisClient) ZRangeByScore(key string, opt *redis.ZRangeBy) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ZRangeByScore(rc.ctx, hook, opt)
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ZScore 获取成员的分数 返回float64
+func (rc *RedisClient) ZScore(key string, member interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ZScore(rc.ctx, hook, fmt.Sprint(rc.GetValue(member)))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// ZRank 获取成员的排名 返回int64
+func (rc *RedisClient) ZRank(key string, member interface{}) *Outcome {
+	hook := rc.GetKey(key)
+	cmd := rc.Runner().ZRank(rc.ctx, hook, fmt.Sprint(rc.GetValue(member)))
+	return rc.keyOutcome(key, cmd.Val(), cmd)
+}
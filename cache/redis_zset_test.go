@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestLeaderboard(t *testing.T) {
+	rc, mr := newTestClient(t)
+	added, err := rc.ZAdd("board",
+		redis.Z{Score: 30, Member: "carol"},
+		redis.Z{Score: 10, Member: "alice"},
+		redis.Z{Score: 20, Member: "bob"},
+	).GetInt64()
+	if err != nil || added != 3 {
+		t.Fatalf("zadd: %d, %v", added, err)
+	}
+	if !mr.Exists("app:test:board") {
+		t.Fatal("zset key should be namespaced")
+	}
+	names, _ := rc.ZRange("board", 0, -1).GetArray()
+	if len(names) != 3 || names[0] != "alice" || names[2] != "carol" {
+		t.Fatalf("zrange %v", names)
+	}
+	zs, err := rc.ZRangeWithScores("board", 0, 0).GetZSlice()
+	if err != nil || len(zs) != 1 || zs[0].Member != "alice" || zs[0].Score != 10 {
+		t.Fatalf("zrange withscores %v, %v", zs, err)
+	}
+	mid, _ := rc.ZRangeByScore("board", &redis.ZRangeBy{Min: "15", Max: "+inf"}).GetArray()
+	if len(mid) != 2 || mid[0] != "bob" {
+		t.Fatalf("zrangebyscore %v", mid)
+	}
+	if score, _ := rc.ZScore("board", "bob").GetFloat64(); score != 20 {
+		t.Fatalf("zscore %v", score)
+	}
+	if rank, _ := rc.ZRank("board", "carol").GetInt64(); rank != 2 {
+		t.Fatalf("zrank %d", rank)
+	}
+	if !rc.ZRank("board", "nobody").IsNil() {
+		t.Fatal("rank of a missing member should be a miss")
+	}
+	if n, _ := rc.ZRem("board", "alice").GetInt64(); n != 1 {
+		t.Fatalf("zrem %d", n)
+	}
+	if rank, _ := rc.ZRank("board", "bob").GetInt64(); rank != 0 {
+		t.Fatalf("rank after zrem %d", rank)
+	}
+}
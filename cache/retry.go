@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// retryablePrefixes 集群迁移、主从切换等过程中服务器返回的临时错误
+var retryablePrefixes = []string{"MOVED ", "ASK ", "TRYAGAIN", "LOADING", "CLUSTERDOWN", "READONLY", "MASTERDOWN"}
+
+// WithRetry 遇到临时错误(连接被拒绝、超时、集群迁移等)时重试fn 两次重试的间隔按backoff指数增长
+// 最多执行attempts次 返回最后一次的结果
+func (rc *RedisClient) WithRetry(attempts int, backoff time.Duration, fn func() *Outcome) *Outcome {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	var outcome *Outcome
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(backoff << (i - 1))
+		}
+		outcome = fn()
+		if !IsRetryable(outcome.Error) {
+			return outcome
+		}
+	}
+	return outcome
+}
+
+// IsRetryable 判断是否为可以重试的临时错误
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrCacheMiss) || err == Nil {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range retryablePrefixes {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestWithRetryRecoversFromFlakyCalls(t *testing.T) {
+	rc, _ := newTestClient(t)
+	calls := 0
+	start := time.Now()
+	outcome := rc.WithRetry(5, 10*time.Millisecond, func() *Outcome {
+		calls++
+		if calls <= 2 {
+			return rc.Outcome(nil, fmt.Errorf("dial: %w", syscall.ECONNREFUSED))
+		}
+		return rc.Outcome("ok", nil)
+	})
+	if got, err := outcome.GetString(); outcome.Error != nil || err != nil || got != "ok" {
+		t.Fatalf("outcome = %+v", outcome)
+	}
+	if calls != 3 {
+		t.Fatalf("fn ran %d times, want 3", calls)
+	}
+	// 两次重试分别等待10ms和20ms
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("backoff not applied, took %s", elapsed)
+	}
+}
+
+func TestWithRetryStopsOnPermanentError(t *testing.T) {
+	rc, _ := newTestClient(t)
+	calls := 0
+	boom := errors.New("boom")
+	outcome := rc.WithRetry(5, time.Millisecond, func() *Outcome {
+		calls++
+		return rc.Outcome(nil, boom)
+	})
+	if calls != 1 || outcome.Error != boom {
+		t.Fatalf("calls = %d, err = %v", calls, outcome.Error)
+	}
+}
+
+func TestWithRetryReturnsLastOutcome(t *testing.T) {
+	rc, _ := newTestClient(t)
+	calls := 0
+	outcome := rc.WithRetry(3, time.Millisecond, func() *Outcome {
+		calls++
+		return rc.Outcome(nil, fmt.Errorf("attempt %d: %w", calls, syscall.ECONNRESET))
+	})
+	if calls != 3 || outcome.Error == nil || outcome.Error.Error() != "attempt 3: "+syscall.ECONNRESET.Error() {
+		t.Fatalf("calls = %d, err = %v", calls, outcome.Error)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for err, want := range map[error]bool{
+		nil:                             false,
+		ErrCacheMiss:                    false,
+		redis.Nil:                       false,
+		errors.New("WRONGTYPE"):         false,
+		syscall.ECONNREFUSED:            true,
+		redis.ErrClosed:                 false,
+		proto("MOVED 3999 127.0.0.1:1"): true,
+		proto("TRYAGAIN"):               true,
+		proto("ERR syntax error"):       false,
+	} {
+		if got := IsRetryable(err); got != want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+// proto 模拟服务器返回的错误
+type proto string
+
+func (e proto) Error() string { return string(e) }
+func (e proto) RedisError()   {}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+type timeoutKey struct{}
+
+type cancelKey struct{}
+
+// callerKey 保存加上截止时间前调用方的context
+type callerKey struct{}
+
+// WithTimeout 返回一个浅拷贝 共用连接池 通过该拷贝执行的每条命令都使用d作为截止时间
+// 截止时间只能比 ReadTimeout 更短 需要更长时将 ReadTimeout 设为-1 由调用方控制超时
+// 拷贝共用连接池 不要对拷贝调用 Close
+func (rc *RedisClient) WithTimeout(d time.Duration) *RedisClient {
+	clone := *rc
+	clone.timeout = d
+	clone.ctx = context.WithValue(rc.ctx, timeoutKey{}, d)
+	return &clone
+}
+
+// timeoutHook 在每条命令执行前按 WithTimeout 设置的时长加上截止时间
+type timeoutHook struct{}
+
+func (timeoutHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return withDeadline(ctx), nil
+}
+
+func (timeoutHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	cancelDeadline(ctx)
+	return nil
+}
+
+func (timeoutHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return withDeadline(ctx), nil
+}
+
+func (timeoutHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	cancelDeadline(ctx)
+	return nil
+}
+
+func withDeadline(ctx context.Context) context.Context {
+	d, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	if !ok || d <= 0 {
+		return ctx
+	}
+	caller := ctx
+	ctx, cancel := context.WithTimeout(ctx, d)
+	ctx = context.WithValue(ctx, callerKey{}, caller)
+	return context.WithValue(ctx, cancelKey{}, cancel)
+}
+
+// callerDone 调用方的context已取消或超时 WithTimeout 加上的截止时间不算
+func callerDone(ctx context.Context) bool {
+	if caller, ok := ctx.Value(callerKey{}).(context.Context); ok {
+		return caller.Err() != nil
+	}
+	return ctx.Err() != nil
+}
+
+func cancelDeadline(ctx context.Context) {
+	if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
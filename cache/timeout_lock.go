@@ -1,9 +1,30 @@
 package cache
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/go-redis/redis/v8"
+	"sync"
 	"time"
 )
 
+// unlockScript 值与持有者一致时才删除 保证判断和删除是原子的
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript 值与持有者一致时才续期
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
 type TimeoutLocker struct {
 	TimeOut time.Duration
 	ReUse bool
@@ -17,45 +38,120 @@ func NewTimeOutLock(timeout time.Duration, reuse bool) *TimeoutLocker {
 	}
 }
 
+// Lock 加锁 先原子的 SET NX 抢锁，失败时若允许重入且持有者是自己则续期
 func (tl *TimeoutLocker) Lock(name string, topic string) bool {
-	redis := GetRedis()
-	value := redis.Get(name)
-	if value.Error != nil {
-		if value.Error == Nil {
-			nx := redis.SetNX(name, topic, tl.TimeOut)
-			if bol, err := nx.GetBool(); bol && err == nil {
-				return true
-			} else {
-				return false
-			}
-		} else {
-			return false
-		}
-	} else {
-		if str, err := value.GetString(); err == nil && str != topic {
-			return false
-		} else {
-			if !tl.ReUse {
-				return false
-			} else {
-				expire := redis.Expire(name, tl.TimeOut)
-				if bol, err := expire.GetBool();bol && err == nil {
-					after := redis.Get(name)
-					if after.Error == nil {
-						if str, err := after.GetString(); err == nil && str == topic {
-							return true
-						}
-					}
-					return false
-				} else {
-					nx := redis.SetNX(name, topic, tl.TimeOut)
-					if bol, err := nx.GetBool(); bol && err == nil {
-						return true
-					} else {
-						return false
-					}
-				}
+	rc := GetRedis()
+	nx := tl.setNX(rc, name, topic)
+	if nx.Error != nil {
+		return false
+	}
+	if bol, err := nx.GetBool(); bol && err == nil {
+		return true
+	}
+	if !tl.ReUse {
+		return false
+	}
+	if tl.renew(rc, name, topic) {
+		return true
+	}
+	// 续期失败说明锁在此期间已过期，再尝试抢一次
+	nx = tl.setNX(rc, name, topic)
+	if bol, err := nx.GetBool(); bol && err == nil {
+		return true
+	}
+	return false
+}
+
+// LockWithRenewal 加锁并启动看门狗 每隔 TimeOut/3 续期一次
+// 调用 release 或 ctx 结束时停止续期，release 会同时释放锁
+func (tl *TimeoutLocker) LockWithRenewal(ctx context.Context, name, topic string) (release func(), ok bool) {
+	if !tl.Lock(name, topic) {
+		return func() {}, false
+	}
+	done := make(chan struct{})
+	go tl.watchdog(ctx, GetRedis(), name, topic, done)
+	var releaseOnce sync.Once
+	return func() {
+		releaseOnce.Do(func() {
+			close(done)
+			tl.Unlock(name, topic)
+		})
+	}, true
+}
+
+// Unlock 释放锁 只有持有者(topic一致)才能删除 锁已过期或被他人持有返回false
+func (tl *TimeoutLocker) Unlock(name string, topic string) bool {
+	rc := GetRedis()
+	cmd := unlockScript.Run(rc.ctx, rc.Runner(), []string{rc.GetKey(name)}, topic)
+	if n, err := cmd.Int64(); err == nil && n == 1 {
+		return true
+	}
+	return false
+}
+
+// setNX 加锁 锁的超时时间需要精确，不使用 Drift 摆动
+func (tl *TimeoutLocker) setNX(rc *RedisClient, name string, topic string) *Outcome {
+	cmd := rc.Runner().SetNX(rc.ctx, rc.GetKey(name), topic, tl.TimeOut)
+	return rc.keyOutcome(name, cmd.Val(), cmd)
+}
+
+// renew 持有者续期
+func (tl *TimeoutLocker) renew(rc *RedisClient, name string, topic string) bool {
+	cmd := renewScript.Run(rc.ctx, rc.Runner(), []string{rc.GetKey(name)}, topic, tl.TimeOut.Milliseconds())
+	if n, err := cmd.Int64(); err == nil && n == 1 {
+		return true
+	}
+	return false
+}
+
+// watchdog 定时续期 锁已不属于自己时退出
+func (tl *TimeoutLocker) watchdog(ctx context.Context, rc *RedisClient, name, topic string, done chan struct{}) {
+	interval := tl.TimeOut / 3
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !tl.renew(rc, name, topic) {
+				return
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+// SetNXToken key不存在时写入一个随机令牌 返回令牌和是否写入成功 过期时间不使用 Drift 摆动
+// 令牌用于之后通过 ReleaseToken 比较并删除 防止误删他人持有的key
+func (rc *RedisClient) SetNXToken(key string, expiration time.Duration) (string, bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return Null, false, err
+	}
+	token := hex.EncodeToString(buf)
+	cmd := rc.Runner().SetNX(rc.ctx, rc.GetKey(key), token, expiration)
+	rc.invalidate(cmd.Err(), key)
+	if err := cmd.Err(); err != nil {
+		return Null, false, rc.WrapError(cmd.Name(), key, err)
+	}
+	if !cmd.Val() {
+		return Null, false, nil
+	}
+	return token, true, nil
+}
+
+// ReleaseToken 值与令牌一致时才删除key 返回是否删除
+func (rc *RedisClient) ReleaseToken(key string, token string) (bool, error) {
+	cmd := unlockScript.Run(rc.ctx, rc.Runner(), []string{rc.GetKey(key)}, token)
+	rc.invalidate(cmd.Err(), key)
+	n, err := cmd.Int64()
+	if err != nil {
+		return false, rc.WrapError("unlock", key, err)
+	}
+	return n == 1, nil
+}
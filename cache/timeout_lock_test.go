@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUnlockOnlyByOwner(t *testing.T) {
+	mr := initTestRedis(t)
+	tl := NewTimeOutLock(time.Minute, false)
+	if !tl.Lock("job", "a") {
+		t.Fatal("lock should be acquired")
+	}
+	if tl.Unlock("job", "b") {
+		t.Fatal("another owner must not release the lock")
+	}
+	if got, _ := mr.Get("app:test:job"); got != "a" {
+		t.Fatalf("lock value %q, want a", got)
+	}
+	if !tl.Unlock("job", "a") {
+		t.Fatal("owner should release the lock")
+	}
+	if mr.Exists("app:test:job") {
+		t.Fatal("lock key should be deleted")
+	}
+}
+
+func TestUnlockExpiredLock(t *testing.T) {
+	mr := initTestRedis(t)
+	tl := NewTimeOutLock(time.Second, false)
+	if !tl.Lock("job", "a") {
+		t.Fatal("lock should be acquired")
+	}
+	mr.FastForward(2 * time.Second)
+	if tl.Unlock("job", "a") {
+		t.Fatal("expired lock cannot be released")
+	}
+}
+
+func TestLockSingleWinnerUnderContention(t *testing.T) {
+	initTestRedis(t)
+	tl := NewTimeOutLock(time.Minute, false)
+	var (
+		wg      sync.WaitGroup
+		winners int32
+	)
+	start := make(chan struct{})
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			if tl.Lock("job", fmt.Sprintf("owner-%d", i)) {
+				atomic.AddInt32(&winners, 1)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	if winners != 1 {
+		t.Fatalf("%d goroutines acquired the lock, want 1", winners)
+	}
+}
+
+func TestLockReUseByOwner(t *testing.T) {
+	initTestRedis(t)
+	tl := NewTimeOutLock(time.Minute, true)
+	if !tl.Lock("job", "a") || !tl.Lock("job", "a") {
+		t.Fatal("owner should re-acquire a reusable lock")
+	}
+	if tl.Lock("job", "b") {
+		t.Fatal("another owner must not acquire the lock")
+	}
+}
+
+// advance 按真实时间推进miniredis的时钟 让看门狗有机会在过期前续期
+func advance(mr interface{ FastForward(time.Duration) }, total, step time.Duration) {
+	for elapsed := time.Duration(0); elapsed < total; elapsed += step {
+		time.Sleep(step)
+		mr.FastForward(step)
+	}
+}
+
+func TestLockWithRenewalOutlivesTTL(t *testing.T) {
+	mr := initTestRedis(t)
+	tl := NewTimeOutLock(300*time.Millisecond, false)
+	release, ok := tl.LockWithRenewal(context.Background(), "job", "a")
+	if !ok {
+		t.Fatal("lock should be acquired")
+	}
+	advance(mr, time.Second, 20*time.Millisecond)
+	if tl.Lock("job", "b") {
+		t.Fatal("contender acquired a lock that is still being renewed")
+	}
+	release()
+	if !tl.Lock("job", "b") {
+		t.Fatal("contender should acquire the lock after release")
+	}
+}
+
+func TestLockWithoutRenewalExpires(t *testing.T) {
+	mr := initTestRedis(t)
+	tl := NewTimeOutLock(300*time.Millisecond, false)
+	if !tl.Lock("job", "a") {
+		t.Fatal("lock should be acquired")
+	}
+	advance(mr, time.Second, 20*time.Millisecond)
+	if !tl.Lock("job", "b") {
+		t.Fatal("lock without renewal should expire")
+	}
+}
+
+func TestLockWithRenewalStopsOnContextDone(t *testing.T) {
+	mr := initTestRedis(t)
+	tl := NewTimeOutLock(300*time.Millisecond, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, ok := tl.LockWithRenewal(ctx, "job", "a")
+	if !ok {
+		t.Fatal("lock should be acquired")
+	}
+	cancel()
+	advance(mr, time.Second, 20*time.Millisecond)
+	if !tl.Lock("job", "b") {
+		t.Fatal("renewal should stop once the context is done")
+	}
+}
+
+func TestSetNXTokenOwnership(t *testing.T) {
+	rc, mr := newTestClient(t)
+	token, ok, err := rc.SetNXToken("lease", time.Minute)
+	if err != nil || !ok || token == Null {
+		t.Fatalf("first SetNXToken = %q, %v, %v", token, ok, err)
+	}
+	if got, _ := mr.Get("app:test:lease"); got != token {
+		t.Fatalf("stored %q, want the token %q", got, token)
+	}
+	if ttl := mr.TTL("app:test:lease"); ttl != time.Minute {
+		t.Fatalf("ttl %s, want 1m", ttl)
+	}
+	other, ok, err := rc.SetNXToken("lease", time.Minute)
+	if err != nil || ok || other != Null {
+		t.Fatalf("second caller = %q, %v, %v; want ok=false", other, ok, err)
+	}
+	if released, _ := rc.ReleaseToken("lease", "not-the-token"); released {
+		t.Fatal("a wrong token must not release the key")
+	}
+	if released, err := rc.ReleaseToken("lease", token); err != nil || !released {
+		t.Fatalf("ReleaseToken = %v, %v", released, err)
+	}
+
+	seen := map[string]bool{token: true}
+	for i := 0; i < 20; i++ {
+		next, ok, err := rc.SetNXToken(fmt.Sprintf("k%d", i), time.Minute)
+		if err != nil || !ok {
+			t.Fatal(ok, err)
+		}
+		if seen[next] {
+			t.Fatalf("token %q repeated", next)
+		}
+		seen[next] = true
+	}
+}
@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithTimeoutOverridesDeadline(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.MaxRetries = -1 })
+	rc.Set("k", "v", 0)
+	delayCommand(mr, "GET", 150*time.Millisecond)
+
+	start := time.Now()
+	err := rc.WithTimeout(30 * time.Millisecond).Get("k").Error
+	if err == nil {
+		t.Fatal("a short override should time out the slow GET")
+	}
+	if elapsed := time.Since(start); elapsed > 120*time.Millisecond {
+		t.Fatalf("override ignored, took %s", elapsed)
+	}
+	if !IsRetryable(err) {
+		t.Fatalf("timeout should be a network error, got %v", err)
+	}
+
+	if got, err := rc.Get("k").GetString(); err != nil || got != "v" {
+		t.Fatalf("the original client should keep the default timeout, got %q, %v", got, err)
+	}
+}
+
+func TestWithTimeoutAppliesToPipelines(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.MaxRetries = -1 })
+	delayCommand(mr, "SET", 150*time.Millisecond)
+	outcomes := rc.WithTimeout(30*time.Millisecond).Pipeline().Set("k", "v", 0).Exec()
+	if outcomes[0].Error == nil {
+		t.Fatal("pipeline should time out under the override")
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook 为每条命令创建一个子span 父span来自调用时传入的context(见 GetCtx 等方法)
+type tracingHook struct {
+	tracer  trace.Tracer
+	hashKey bool
+}
+
+func (h tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", cmd.Name()),
+	}
+	if key, ok := h.key(cmd); ok {
+		attrs = append(attrs, attribute.String("db.redis.key", key))
+	}
+	ctx, _ = h.tracer.Start(ctx, cmd.Name(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, nil
+}
+
+func (h tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	span := trace.SpanFromContext(ctx)
+	recordError(span, cmd.Err())
+	span.End()
+	return nil
+}
+
+func (h tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, _ = h.tracer.Start(ctx, "pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.Int("db.redis.num_cmd", len(cmds)),
+		))
+	return ctx, nil
+}
+
+func (h tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	span := trace.SpanFromContext(ctx)
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && cmd.Err() != Nil {
+			recordError(span, cmd.Err())
+			break
+		}
+	}
+	span.End()
+	return nil
+}
+
+// key 命令的第一个参数即为key 开启 HashTraceKey 时记录sha256摘要
+func (h tracingHook) key(cmd redis.Cmder) (string, bool) {
+	key := commandKey(cmd)
+	if key == Null {
+		return Null, false
+	}
+	if h.hashKey {
+		sum := sha256.Sum256([]byte(key))
+		key = hex.EncodeToString(sum[:])
+	}
+	return key, true
+}
+
+// recordError 未命中不算错误
+func recordError(span trace.Span, err error) {
+	if err == nil || err == Nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
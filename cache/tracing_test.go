@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracedClient(t *testing.T, hashKey bool) (*RedisClient, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	rc, _ := newTestClient(t, func(o *Options) {
+		o.Tracer = provider.Tracer("cache")
+		o.HashTraceKey = hashKey
+	})
+	return rc, recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (string, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.AsString(), true
+		}
+	}
+	return "", false
+}
+
+func TestTracingSpanForGet(t *testing.T) {
+	rc, recorder := newTracedClient(t, false)
+	_ = rc.Get("user").Error
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "get" {
+		t.Fatalf("span name %q", span.Name())
+	}
+	if key, _ := spanAttr(span, "db.redis.key"); key != "app:test:user" {
+		t.Fatalf("db.redis.key = %q", key)
+	}
+	if op, _ := spanAttr(span, "db.operation"); op != "get" {
+		t.Fatalf("db.operation = %q", op)
+	}
+	// 未命中不记为错误
+	if span.Status().Code == codes.Error {
+		t.Fatal("a miss should not mark the span as error")
+	}
+}
+
+func TestTracingHashesKey(t *testing.T) {
+	rc, recorder := newTracedClient(t, true)
+	_ = rc.Get("user").Error
+
+	sum := sha256.Sum256([]byte("app:test:user"))
+	if key, _ := spanAttr(recorder.Ended()[0], "db.redis.key"); key != hex.EncodeToString(sum[:]) {
+		t.Fatalf("db.redis.key = %q", key)
+	}
+}
+
+func TestTracingRecordsErrorStatus(t *testing.T) {
+	rc, recorder := newTracedClient(t, false)
+	if err := rc.Set("user", "bob", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	_ = rc.HGet("user", "name").Error
+
+	spans := recorder.Ended()
+	if span := spans[len(spans)-1]; span.Status().Code != codes.Error {
+		t.Fatalf("WRONGTYPE should mark the span as error, got %v", span.Status())
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"time"
+)
+
+// DefaultTxRetries 默认的乐观事务重试次数
+const DefaultTxRetries = 3
+
+// Tx 乐观事务 读操作立即执行 写操作在回调返回后通过 MULTI/EXEC 一次提交
+type Tx struct {
+	rc     *RedisClient
+	tx     *redis.Tx
+	writes []func(pipe redis.Pipeliner)
+	// written 写操作涉及的key 提交后使本地缓存失效
+	written []string
+}
+
+// Transact 监视keys并执行fn 被监视的key在提交前被修改时自动重试
+// 重试次数由 Options.TxRetries 控制 为0时使用 DefaultTxRetries
+func (rc *RedisClient) Transact(keys []string, fn func(tx *Tx) error) error {
+	retries := rc.opt.TxRetries
+	if retries <= 0 {
+		retries = DefaultTxRetries
+	}
+	hooks := rc.getHooks(keys)
+	txf := func(tx *redis.Tx) error {
+		t := &Tx{rc: rc, tx: tx}
+		if err := fn(t); err != nil {
+			return err
+		}
+		return t.commit()
+	}
+	var err error
+	for i := 0; i < retries; i++ {
+		if rc.flag {
+			err = rc.single.Watch(rc.ctx, txf, hooks...)
+		} else {
+			err = rc.cluster.Watch(rc.ctx, txf, hooks...)
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return err
+}
+
+// commit 提交排队的写操作
+func (t *Tx) commit() error {
+	if len(t.writes) == 0 {
+		return nil
+	}
+	_, err := t.tx.TxPipelined(t.rc.ctx, func(pipe redis.Pipeliner) error {
+		for _, write := range t.writes {
+			write(pipe)
+		}
+		return nil
+	})
+	t.rc.invalidate(err, t.written...)
+	return err
+}
+
+// Get 获取值 返回string
+func (t *Tx) Get(key string) *Outcome {
+	cmd := t.tx.Get(t.rc.ctx, t.rc.GetKey(key))
+	return t.rc.keyOutcome(key, cmd.Val(), cmd)
+}
+
+// Set 在提交时set值
+func (t *Tx) Set(key string, value interface{}, expiration time.Duration) {
+	hook, value := t.rc.GetKey(key), t.rc.GetValue(value)
+	expiration = t.rc.Drift(expiration)
+	t.written = append(t.written, key)
+	t.writes = append(t.writes, func(pipe redis.Pipeliner) {
+		pipe.Set(t.rc.ctx, hook, value, expiration)
+	})
+}
+
+// Del 在提交时删除key
+func (t *Tx) Del(keys ...string) {
+	hooks := t.rc.getHooks(keys)
+	t.written = append(t.written, keys...)
+	t.writes = append(t.writes, func(pipe redis.Pipeliner) {
+		pipe.Del(t.rc.ctx, hooks...)
+	})
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// txIncr 通过WATCH实现的自增
+func txIncr(rc *RedisClient, key string, before func()) error {
+	return rc.Transact([]string{key}, func(tx *Tx) error {
+		var n int64
+		outcome := tx.Get(key)
+		if outcome.Error == nil {
+			str, _ := outcome.GetString()
+			n, _ = strconv.ParseInt(str, 10, 64)
+		} else if !outcome.IsNil() {
+			return outcome.Error
+		}
+		if before != nil {
+			before()
+		}
+		tx.Set(key, strconv.FormatInt(n+1, 10), 0)
+		return nil
+	})
+}
+
+func TestTransactRetriesOnConcurrentModification(t *testing.T) {
+	rc, mr := newTestClient(t)
+	attempts := 0
+	err := txIncr(rc, "n", func() {
+		attempts++
+		if attempts == 1 {
+			// 第一次尝试期间其他客户端修改了被监视的key
+			if err := rc.Set("n", "10", 0).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Fatalf("fn ran %d times, want a retry", attempts)
+	}
+	if got, _ := mr.Get("app:test:n"); got != "11" {
+		t.Fatalf("n = %q, want the retry to read the new value", got)
+	}
+}
+
+func TestTransactConcurrentIncrements(t *testing.T) {
+	rc, mr := newTestClient(t, func(o *Options) { o.TxRetries = 1000 })
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- txIncr(rc, "n", nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _ := mr.Get("app:test:n"); got != strconv.Itoa(n) {
+		t.Fatalf("n = %q, want %d", got, n)
+	}
+}
+
+func TestTransactGivesUpAfterRetries(t *testing.T) {
+	rc, _ := newTestClient(t, func(o *Options) { o.TxRetries = 2 })
+	attempts := 0
+	err := txIncr(rc, "n", func() {
+		attempts++
+		_ = rc.Set("n", attempts, 0)
+	})
+	if !errors.Is(err, redis.TxFailedErr) {
+		t.Fatalf("err = %v, want TxFailedErr", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("fn ran %d times, want 2", attempts)
+	}
+}
+
+func TestTxDelAndNamespacedWatch(t *testing.T) {
+	rc, mr := newTestClient(t)
+	if err := rc.Set("a", "1", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Transact([]string{"a"}, func(tx *Tx) error {
+		tx.Del("a")
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("app:test:a") {
+		t.Fatal("Tx.Del should remove the namespaced key")
+	}
+}
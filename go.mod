@@ -1,10 +1,22 @@
 module bonbon-common
 
-go 1.17
+go 1.18
 
-require github.com/go-redis/redis v6.15.9+incompatible
+require (
+	github.com/alicebob/miniredis/v2 v2.35.0
+	github.com/go-redis/redis/v8 v8.11.5
+	github.com/golang/snappy v0.0.4
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+	golang.org/x/sync v0.1.0
+)
 
 require (
-	github.com/onsi/ginkgo v1.16.4 // indirect
-	github.com/onsi/gomega v1.16.0 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )